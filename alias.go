@@ -2,17 +2,37 @@ package seng
 
 // HTTP header
 const (
-	HeaderContentType        = "Content-Type"
-	MIMETextPlainCharsetUTF8 = "text/plain;charset=utf-8"
-	MINEApplicationJSON      = "application/json;charset=utf-8"
-	MINEApplicationProtobuf  = "application/protobuf;charset=utf-8"
-	MINETextHTML             = "text/html;charset=utf-8"
-	ContentTypeJson          = "application/json"
-	ContentTypeTextPlain     = "text/plain"
-	ContentTypeTextHtml      = "text/html"
-	ContentTypeXml           = "application/xml"
-	CharsetSuffix            = ";charset=utf-8"
-	HeaderAccept             = "Accept"
+	HeaderContentType         = "Content-Type"
+	MIMETextPlainCharsetUTF8  = "text/plain;charset=utf-8"
+	MINEApplicationJSON       = "application/json;charset=utf-8"
+	MINEApplicationProtobuf   = "application/protobuf;charset=utf-8"
+	MINETextHTML              = "text/html;charset=utf-8"
+	ContentTypeJson           = "application/json"
+	ContentTypeTextPlain      = "text/plain"
+	ContentTypeTextHtml       = "text/html"
+	ContentTypeXml            = "application/xml"
+	ContentTypeYaml           = "application/yaml"
+	MIMEApplicationJavaScript = "text/javascript;charset=utf-8"
+	MIMEApplicationNDJSON     = "application/x-ndjson"
+	HeaderXContentTypeOptions = "X-Content-Type-Options"
+	ContentTypeForm           = "application/x-www-form-urlencoded"
+	ContentTypeMultipartForm  = "multipart/form-data"
+	CharsetSuffix             = ";charset=utf-8"
+	HeaderAccept              = "Accept"
+	HeaderAcceptLanguage      = "Accept-Language"
+	HeaderAcceptEncoding      = "Accept-Encoding"
+	HeaderContentEncoding     = "Content-Encoding"
+	HeaderAcceptCharset       = "Accept-Charset"
+	HeaderVary                = "Vary"
+	MIMETextEventStream       = "text/event-stream"
+	HeaderCacheControl        = "Cache-Control"
+	HeaderConnection          = "Connection"
+	HeaderLastEventID         = "Last-Event-ID"
+	HeaderXAccelBuffering     = "X-Accel-Buffering"
+	HeaderContentDisposition  = "Content-Disposition"
+	HeaderETag                = "ETag"
+	HeaderCookie              = "Cookie"
+	HeaderSetCookie           = "Set-Cookie"
 )
 
 // limits for HTTP statuscodes
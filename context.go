@@ -1,6 +1,7 @@
 package seng
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -16,8 +17,10 @@ type Context struct {
 	// reference to router
 	router *Router
 	// origin objects
-	// http Writer
+	// http Writer, a ResponseWriter unless replaced by a middleware
 	Writer http.ResponseWriter
+	// response wraps the origin http.ResponseWriter
+	response responseWriter
 	// http Request
 	Request *http.Request
 	// request info
@@ -37,6 +40,18 @@ type Context struct {
 	Values map[string]interface{}
 	// user context
 	userContext context.Context
+	// locale set by SetLocale
+	locale string
+	// see SetViewData
+	viewData Map
+	// see RenderStream
+	streaming bool
+	// see Flash
+	flash *flashState
+	// see OnSetCookie
+	cookieHooks []func(cookie *http.Cookie) error
+	// see SetRequestCookies, nil for the cookies of Request
+	requestCookies []*http.Cookie
 }
 
 // NewContext new context with default
@@ -48,14 +63,21 @@ func NewContext(w http.ResponseWriter, req *http.Request) *Context {
 
 // init Context
 func (c *Context) init(w http.ResponseWriter, req *http.Request) {
-	c.Writer = w
+	c.response.reset(w)
+	c.Writer = &c.response
 	c.Request = req
 	c.Method = req.Method
 	c.Path = req.URL.Path
 	c.HostName = req.Host
 	c.Values = make(map[string]interface{})
 	c.indexHandler = -1
+	c.StatusCode = http.StatusOK
 	c.userContext = context.Background()
+	c.locale = ""
+	c.viewData = nil
+	c.flash = nil
+	c.cookieHooks = c.cookieHooks[:0]
+	c.requestCookies = nil
 }
 
 // ReSet context from w,req
@@ -75,6 +97,11 @@ func (c *Context) Router() *Router {
 	return c.router
 }
 
+// Response returns the ResponseWriter of the request
+func (c *Context) Response() ResponseWriter {
+	return &c.response
+}
+
 // UserContext return user context
 func (c *Context) UserContext() context.Context {
 	return c.userContext
@@ -137,6 +164,36 @@ func (c *Context) GetHeaderValues(key string) []string {
 	return c.Writer.Header().Values(key)
 }
 
+// SetLocale overrides the locale of the request, e.g. from a user setting
+func (c *Context) SetLocale(locale string) {
+	c.locale = locale
+}
+
+// Locale returns the locale set by SetLocale,
+// or the preferred language of the Accept-Language header
+func (c *Context) Locale() string {
+	if locales := c.Locales(); len(locales) > 0 {
+		return locales[0]
+	}
+	return ""
+}
+
+// Locales returns the locale set by SetLocale,
+// or the languages of the Accept-Language header by preference
+func (c *Context) Locales() []string {
+	if c.locale != "" {
+		return []string{c.locale}
+	}
+	specs := parseAccept(c.GetHeader(HeaderAcceptLanguage))
+	locales := make([]string, 0, len(specs))
+	for _, spec := range specs {
+		if spec.value != "*" && spec.q > 0 {
+			locales = append(locales, spec.value)
+		}
+	}
+	return locales
+}
+
 // IP get remote ip address
 func (c *Context) IP() string {
 	return c.Request.RemoteAddr
@@ -186,13 +243,21 @@ func (c *Context) Query(key string, defaultValue ...string) string {
 	return defaultString(c.Request.URL.Query().Get(key), defaultValue)
 }
 
-// Status set response status code
+// Status set response status code, it is sent with the first write
+// so headers can still be set afterwards
 func (c *Context) Status(code int) *Context {
 	c.StatusCode = code
 	c.Writer.WriteHeader(code)
 	return c
 }
 
+// BeforeWrite registers fn to run before the status code and headers are
+// sent, e.g. to set cookies. Functions run in reverse order like deferred
+// calls, so those of outer middlewares see the changes of inner ones.
+func (c *Context) BeforeWrite(fn func()) {
+	c.response.beforeWrite = append(c.response.beforeWrite, fn)
+}
+
 // Text return text
 func (c *Context) Text(format string, values ...interface{}) (err error) {
 	c.SetHeader(HeaderContentType, MIMETextPlainCharsetUTF8)
@@ -203,14 +268,17 @@ func (c *Context) Text(format string, values ...interface{}) (err error) {
 	return
 }
 
-// JSON return JSON
+// JSON return JSON followed by a newline, indented in debug mode
 func (c *Context) JSON(obj interface{}) (err error) {
-	c.SetHeader(HeaderContentType, MINEApplicationJSON)
-	encoder := json.NewEncoder(c.Writer)
+	buf := new(bytes.Buffer)
+	encoder := json.NewEncoder(buf)
+	if c.debug() {
+		encoder.SetIndent("", "    ")
+	}
 	if err = encoder.Encode(obj); err != nil {
 		return err
 	}
-	return
+	return c.writeContent(MINEApplicationJSON, buf.Bytes())
 }
 
 // Protobuf return protobuf
@@ -234,13 +302,9 @@ func (c *Context) Fail(code int, err string) error {
 	return c.JSON(NewError(code, err))
 }
 
-// HTML renders HTML
-func (c *Context) HTML(name string, data interface{}) (err error) {
-	c.SetHeader(HeaderContentType, MINETextHTML)
-	if err := c.engine.htmlTemplates.ExecuteTemplate(c.Writer, name, data); err != nil {
-		return c.Fail(http.StatusInternalServerError, err.Error())
-	}
-	return
+// HTML renders the template name, with Config.Views when it is set, see Render
+func (c *Context) HTML(name string, data interface{}) error {
+	return c.Render(name, data)
 }
 
 // Param get values from route parameters
@@ -1,41 +1,268 @@
 package seng
 
 import (
+	"errors"
 	"net/http"
+	"time"
 )
 
+// ErrInsecureCookie is returned for SameSite=None or partitioned cookies which are not Secure
+var ErrInsecureCookie = errors.New("seng: SameSite=None and partitioned cookies must be Secure")
+
+// DefaultCookiePath default CookieConfig.Path
+const DefaultCookiePath = "/"
+
+// CookieConfig default attributes of the cookies set by Context.Cookie,
+// SetCookieWithValue and ClearCookie
+type CookieConfig struct {
+	// Default: "/"
+	Path string `json:"path"`
+	// Default: the host of the request
+	Domain string `json:"domain"`
+	// Secure cookies are only sent over HTTPS
+	Secure bool `json:"secure"`
+	// HttpOnly cookies cannot be read by JavaScript
+	HttpOnly bool `json:"http_only"`
+	// http.SameSiteStrictMode http.SameSiteLaxMode http.SameSiteNoneMode,
+	// http.SameSiteNoneMode must set Secure to true
+	// Default: Config.CookieSameSite
+	SameSite http.SameSite `json:"same_site"`
+	// Partitioned cookies are stored per top-level site (CHIPS), they must be Secure
+	Partitioned bool `json:"partitioned"`
+}
+
+// validateCookie checks that SameSite=None and partitioned cookies are Secure
+func validateCookie(cookie *http.Cookie) error {
+	if (cookie.SameSite == http.SameSiteNoneMode || cookie.Partitioned) && !cookie.Secure {
+		return ErrInsecureCookie
+	}
+	return nil
+}
+
+// newCookie returns a cookie with the attributes of Config.Cookie
+func (c *Context) newCookie(name, value string) *http.Cookie {
+	config := c.engine.config.Cookie
+	return &http.Cookie{
+		Name:        name,
+		Value:       value,
+		Path:        config.Path,
+		Domain:      config.Domain,
+		Secure:      config.Secure,
+		HttpOnly:    config.HttpOnly,
+		SameSite:    config.SameSite,
+		Partitioned: config.Partitioned,
+	}
+}
+
 // GetCookie get cookie by key
 func (c *Context) GetCookie(name string) (*http.Cookie, error) {
-	return c.Request.Cookie(name)
+	if c.requestCookies == nil {
+		return c.Request.Cookie(name)
+	}
+	for _, cookie := range c.requestCookies {
+		if cookie.Name == name {
+			return cookie, nil
+		}
+	}
+	return nil, http.ErrNoCookie
 }
 
 // GetCookies get all cookies
 func (c *Context) GetCookies() []*http.Cookie {
-	return c.Request.Cookies()
+	if c.requestCookies == nil {
+		return c.Request.Cookies()
+	}
+	return c.requestCookies
+}
+
+// SetRequestCookies replaces the cookies of the request read with the Context
+// for the next handlers, e.g. with the cookies a middleware decrypted. The
+// Cookie header of Request is not changed.
+func (c *Context) SetRequestCookies(cookies []*http.Cookie) {
+	if cookies == nil {
+		cookies = []*http.Cookie{}
+	}
+	c.requestCookies = cookies
 }
 
-// SetCookie set cookie
+// SetCookie set cookie, after the functions registered with OnSetCookie
+// changed a copy of it. SameSite=None and partitioned cookies which are not
+// Secure are logged and not set, browsers reject them.
 func (c *Context) SetCookie(cookie *http.Cookie) {
+	if err := validateCookie(cookie); err != nil {
+		c.logCookieError(cookie, err)
+		return
+	}
+	if len(c.cookieHooks) > 0 {
+		changed := *cookie
+		cookie = &changed
+		for _, hook := range c.cookieHooks {
+			if err := hook(cookie); err != nil {
+				c.logCookieError(cookie, err)
+				return
+			}
+		}
+	}
 	http.SetCookie(c.Writer, cookie)
-	return
 }
 
-// SetCookieWithValue set cookie with key value expires
+// logCookieError logs the error of a cookie which is not set
+func (c *Context) logCookieError(cookie *http.Cookie, err error) {
+	if c.engine != nil {
+		c.engine.Logger.Printf("seng: cookie %s: %v", cookie.Name, err)
+	}
+}
+
+// OnSetCookie registers hook to change the cookies set with the Context
+// before they are written to the headers, the cookie is not set if hook
+// returns an error
+func (c *Context) OnSetCookie(hook func(cookie *http.Cookie) error) {
+	c.cookieHooks = append(c.cookieHooks, hook)
+}
+
+// SetCookieWithValue set cookie with key value expires, Path, Domain,
+// SameSite and Partitioned are those of Config.Cookie. SameSite=None and
+// partitioned cookies are not set without secure, see SetCookie.
 func (c *Context) SetCookieWithValue(key, value string, expires int, httpOnly bool, secure bool) {
-	cookie := &http.Cookie{
-		Name:   key,
-		Value:  value,
-		MaxAge: expires,
-		// Set httponly = true cookies cannot be obtained by JS, unable
-		// to use Document.cookie to play cookie content.
-		HttpOnly: httpOnly,
-		// If a cookie is set for secure = true, this cookie can only be sent
-		// to the server with HTTPS protocol.
-		Secure: secure,
-		// http.SameSiteStrictMode http.SameSiteLaxMode http.SameSiteNoneMode
-		// http.SameSiteNoneMode must set secure to true
-		SameSite: c.engine.config.CookieSameSite,
+	cookie := c.newCookie(key, value)
+	cookie.MaxAge = expires
+	// Set httponly = true cookies cannot be obtained by JS, unable
+	// to use Document.cookie to play cookie content.
+	cookie.HttpOnly = httpOnly
+	// If a cookie is set for secure = true, this cookie can only be sent
+	// to the server with HTTPS protocol.
+	cookie.Secure = secure
+	c.SetCookie(cookie)
+}
+
+// ClearCookie expires the cookies names, or all cookies of the request
+// without names, with the Path and Domain of Config.Cookie. Cookies set with
+// another Path or Domain are cleared with CookieBuilder.Clear.
+func (c *Context) ClearCookie(names ...string) {
+	if len(names) == 0 {
+		for _, cookie := range c.GetCookies() {
+			names = append(names, cookie.Name)
+		}
 	}
-	http.SetCookie(c.Writer, cookie)
-	return
+	for _, name := range names {
+		c.clearCookie(c.newCookie(name, ""))
+	}
+}
+
+// clearCookie expires cookie
+func (c *Context) clearCookie(cookie *http.Cookie) {
+	cookie.Value = ""
+	cookie.MaxAge = -1
+	cookie.Expires = time.Unix(0, 0)
+	c.SetCookie(cookie)
+}
+
+// CookieBuilder builds a cookie with the defaults of Config.Cookie, see Context.Cookie
+type CookieBuilder struct {
+	c      *Context
+	cookie *http.Cookie
+	// signed or encrypted by Set
+	signed    bool
+	encrypted bool
+}
+
+// Cookie returns a builder of the cookie name with the defaults of Config.Cookie
+// return c.Cookie("theme").Value("dark").MaxAge(30 * 24 * time.Hour).Set()
+func (c *Context) Cookie(name string) *CookieBuilder {
+	return &CookieBuilder{c: c, cookie: c.newCookie(name, "")}
+}
+
+// Value sets the value
+func (b *CookieBuilder) Value(value string) *CookieBuilder {
+	b.cookie.Value = value
+	return b
+}
+
+// Path sets the path
+func (b *CookieBuilder) Path(path string) *CookieBuilder {
+	b.cookie.Path = path
+	return b
+}
+
+// Domain sets the domain
+func (b *CookieBuilder) Domain(domain string) *CookieBuilder {
+	b.cookie.Domain = domain
+	return b
+}
+
+// MaxAge sets Max-Age and Expires, the cookie expires after maxAge
+func (b *CookieBuilder) MaxAge(maxAge time.Duration) *CookieBuilder {
+	b.cookie.MaxAge = int(maxAge / time.Second)
+	b.cookie.Expires = time.Now().Add(maxAge)
+	return b
+}
+
+// Expires sets Expires
+func (b *CookieBuilder) Expires(expires time.Time) *CookieBuilder {
+	b.cookie.Expires = expires
+	return b
+}
+
+// Secure sets Secure
+func (b *CookieBuilder) Secure(secure bool) *CookieBuilder {
+	b.cookie.Secure = secure
+	return b
+}
+
+// HttpOnly sets HttpOnly
+func (b *CookieBuilder) HttpOnly(httpOnly bool) *CookieBuilder {
+	b.cookie.HttpOnly = httpOnly
+	return b
+}
+
+// SameSite sets SameSite
+func (b *CookieBuilder) SameSite(sameSite http.SameSite) *CookieBuilder {
+	b.cookie.SameSite = sameSite
+	return b
+}
+
+// Partitioned sets Partitioned
+func (b *CookieBuilder) Partitioned(partitioned bool) *CookieBuilder {
+	b.cookie.Partitioned = partitioned
+	return b
+}
+
+// Signed signs the value, see Context.SetSignedCookie
+func (b *CookieBuilder) Signed() *CookieBuilder {
+	b.signed = true
+	return b
+}
+
+// Encrypted encrypts the value, see Context.SetEncryptedCookie
+func (b *CookieBuilder) Encrypted() *CookieBuilder {
+	b.encrypted = true
+	return b
+}
+
+// Set sets the cookie, it returns ErrInsecureCookie for SameSite=None or
+// partitioned cookies which are not Secure
+func (b *CookieBuilder) Set() error {
+	if err := validateCookie(b.cookie); err != nil {
+		return err
+	}
+	switch {
+	case b.encrypted:
+		return b.c.SetEncryptedCookie(b.cookie)
+	case b.signed:
+		return b.c.SetSignedCookie(b.cookie)
+	}
+	b.c.SetCookie(b.cookie)
+	return nil
+}
+
+// Clear expires the cookie with its Path and Domain, which must be those it
+// was set with, it returns ErrInsecureCookie like Set
+// c.Cookie("uid").Path("/admin").Clear()
+func (b *CookieBuilder) Clear() error {
+	if err := validateCookie(b.cookie); err != nil {
+		return err
+	}
+	cookie := *b.cookie
+	b.c.clearCookie(&cookie)
+	return nil
 }
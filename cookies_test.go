@@ -0,0 +1,256 @@
+package seng
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOnSetCookie(t *testing.T) {
+	upper := func(cookie *http.Cookie) error {
+		cookie.Value = strings.ToUpper(cookie.Value)
+		return nil
+	}
+	reject := func(cookie *http.Cookie) error {
+		if cookie.Name == "secret" {
+			return errors.New("rejected")
+		}
+		return nil
+	}
+	tests := []struct {
+		name  string
+		hooks []func(cookie *http.Cookie) error
+		set   func(c *Context)
+		want  []string
+	}{
+		{"no hooks", nil, func(c *Context) { c.SetCookie(&http.Cookie{Name: "a", Value: "x"}) }, []string{"a=x"}},
+		{"SetCookie", []func(*http.Cookie) error{upper}, func(c *Context) {
+			c.SetCookie(&http.Cookie{Name: "a", Value: "x"})
+		}, []string{"a=X"}},
+		{"SetCookieWithValue", []func(*http.Cookie) error{upper}, func(c *Context) {
+			c.SetCookieWithValue("a", "x", 60, false, false)
+		}, []string{"a=X; Path=/; Max-Age=60; SameSite=Lax"}},
+		{"builder", []func(*http.Cookie) error{upper}, func(c *Context) {
+			_ = c.Cookie("a").Value("x").Set()
+		}, []string{"a=X; Path=/; SameSite=Lax"}},
+		{"rejected", []func(*http.Cookie) error{upper, reject}, func(c *Context) {
+			c.SetCookie(&http.Cookie{Name: "secret", Value: "x"})
+			c.SetCookie(&http.Cookie{Name: "a", Value: "x"})
+		}, []string{"a=X"}},
+		{"insecure", []func(*http.Cookie) error{upper}, func(c *Context) {
+			c.SetCookie(&http.Cookie{Name: "none", Value: "x", SameSite: http.SameSiteNoneMode})
+			c.SetCookie(&http.Cookie{Name: "a", Value: "x"})
+		}, []string{"a=X"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New()
+			e.GET("/", func(c *Context) error {
+				for _, hook := range tt.hooks {
+					c.OnSetCookie(hook)
+				}
+				tt.set(c)
+				return nil
+			})
+			got := serve(t, e, http.MethodGet, "/", "").Header()[HeaderSetCookie]
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("Set-Cookie = %q, want %q", got, tt.want)
+			}
+			// hooks do not outlive the request of the pooled context
+			e.GET("/plain", func(c *Context) error {
+				c.SetCookie(&http.Cookie{Name: "b", Value: "y"})
+				return nil
+			})
+			if got := serve(t, e, http.MethodGet, "/plain", "").Header().Get(HeaderSetCookie); got != "b=y" {
+				t.Errorf("next request Set-Cookie = %q", got)
+			}
+		})
+	}
+}
+
+// setCookies returns the Set-Cookie headers of handler
+func setCookies(t *testing.T, config Config, handler Handler, headers ...string) []string {
+	t.Helper()
+	e := New(config)
+	e.GET("/", handler)
+	return serve(t, e, http.MethodGet, "/", "", headers...).Header()[HeaderSetCookie]
+}
+
+func TestCookieBuilder(t *testing.T) {
+	partitioned := Config{Cookie: CookieConfig{Domain: "example.com", Secure: true, HttpOnly: true, SameSite: http.SameSiteNoneMode, Partitioned: true}}
+	tests := []struct {
+		name    string
+		config  Config
+		build   func(b *CookieBuilder) *CookieBuilder
+		want    string
+		wantErr error
+	}{
+		{"defaults", Config{}, func(b *CookieBuilder) *CookieBuilder { return b.Value("dark") }, "theme=dark; Path=/; SameSite=Lax", nil},
+		{"config", partitioned, func(b *CookieBuilder) *CookieBuilder { return b.Value("dark") },
+			"theme=dark; Path=/; Domain=example.com; HttpOnly; Secure; SameSite=None; Partitioned", nil},
+		{"overrides", partitioned, func(b *CookieBuilder) *CookieBuilder {
+			return b.Value("dark").Path("/app").Domain("app.example.com").HttpOnly(false).SameSite(http.SameSiteStrictMode).Partitioned(false)
+		}, "theme=dark; Path=/app; Domain=app.example.com; Secure; SameSite=Strict", nil},
+		{"max age", Config{}, func(b *CookieBuilder) *CookieBuilder { return b.Value("dark").MaxAge(time.Minute) }, "Max-Age=60", nil},
+		{"SameSite=None without Secure", Config{}, func(b *CookieBuilder) *CookieBuilder {
+			return b.Value("dark").SameSite(http.SameSiteNoneMode)
+		}, "", ErrInsecureCookie},
+		{"partitioned without Secure", partitioned, func(b *CookieBuilder) *CookieBuilder {
+			return b.Value("dark").SameSite(http.SameSiteLaxMode).Secure(false)
+		}, "", ErrInsecureCookie},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			got := setCookies(t, tt.config, func(c *Context) error {
+				err = tt.build(c.Cookie("theme")).Set()
+				return nil
+			})
+			if err != tt.wantErr {
+				t.Fatalf("Set = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if len(got) != 0 {
+					t.Errorf("Set-Cookie = %q", got)
+				}
+				return
+			}
+			if len(got) != 1 || !strings.Contains(got[0], tt.want) {
+				t.Errorf("Set-Cookie = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInsecureCookie(t *testing.T) {
+	insecure := http.Cookie{Name: "a", Value: "x", SameSite: http.SameSiteNoneMode}
+	sets := []struct {
+		name string
+		set  func(c *Context, cookie *http.Cookie) error
+	}{
+		{"signed", (*Context).SetSignedCookie},
+		{"encrypted", (*Context).SetEncryptedCookie},
+	}
+	for _, set := range sets {
+		got := setCookies(t, Config{CookieKeys: [][]byte{testCookieKey}}, func(c *Context) error {
+			cookie := insecure
+			if err := set.set(c, &cookie); err != ErrInsecureCookie {
+				t.Errorf("%s: error = %v, want ErrInsecureCookie", set.name, err)
+			}
+			return nil
+		})
+		if len(got) != 0 {
+			t.Errorf("%s: Set-Cookie = %q", set.name, got)
+		}
+	}
+
+	defer func() {
+		if err := recover(); err != ErrInsecureCookie {
+			t.Errorf("New panics with %v, want ErrInsecureCookie", err)
+		}
+	}()
+	New(Config{Cookie: CookieConfig{SameSite: http.SameSiteNoneMode}})
+}
+
+func TestSetCookieWithValue(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		secure bool
+		want   string
+	}{
+		{"defaults", Config{}, false, "a=x; Path=/; Max-Age=60; HttpOnly; SameSite=Lax"},
+		{"secure", Config{}, true, "a=x; Path=/; Max-Age=60; HttpOnly; Secure; SameSite=Lax"},
+		{"SameSite=None", Config{Cookie: CookieConfig{Secure: true, SameSite: http.SameSiteNoneMode}}, true,
+			"a=x; Path=/; Max-Age=60; HttpOnly; Secure; SameSite=None"},
+		{"SameSite=None without secure", Config{Cookie: CookieConfig{Secure: true, SameSite: http.SameSiteNoneMode}}, false, ""},
+		{"partitioned without secure", Config{Cookie: CookieConfig{Secure: true, Partitioned: true}}, false, ""},
+	}
+	for _, tt := range tests {
+		got := setCookies(t, tt.config, func(c *Context) error {
+			c.SetCookieWithValue("a", "x", 60, true, tt.secure)
+			return nil
+		})
+		if tt.want == "" {
+			if len(got) != 0 {
+				t.Errorf("%s: Set-Cookie = %q, want none", tt.name, got)
+			}
+			continue
+		}
+		if len(got) != 1 || got[0] != tt.want {
+			t.Errorf("%s: Set-Cookie = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestClearCookie(t *testing.T) {
+	const expired = "Expires=Thu, 01 Jan 1970 00:00:00 GMT; Max-Age=0"
+	tests := []struct {
+		name    string
+		config  Config
+		clear   func(c *Context)
+		headers []string
+		want    []string
+	}{
+		{"names", Config{}, func(c *Context) { c.ClearCookie("a", "b") }, nil, []string{
+			"a=; Path=/; " + expired + "; SameSite=Lax",
+			"b=; Path=/; " + expired + "; SameSite=Lax",
+		}},
+		{"request cookies", Config{}, func(c *Context) { c.ClearCookie() }, []string{HeaderCookie, "a=1; c=2"}, []string{
+			"a=; Path=/; " + expired + "; SameSite=Lax",
+			"c=; Path=/; " + expired + "; SameSite=Lax",
+		}},
+		{"config", Config{Cookie: CookieConfig{Path: "/app", Domain: "example.com"}}, func(c *Context) { c.ClearCookie("a") }, nil, []string{
+			"a=; Path=/app; Domain=example.com; " + expired + "; SameSite=Lax",
+		}},
+		{"SameSite=None", Config{Cookie: CookieConfig{Secure: true, SameSite: http.SameSiteNoneMode}}, func(c *Context) {
+			c.ClearCookie("a")
+		}, nil, []string{"a=; Path=/; " + expired + "; Secure; SameSite=None"}},
+		{"builder Path and Domain", Config{Cookie: CookieConfig{Domain: "example.com"}}, func(c *Context) {
+			c.Cookie("a").Value("ignored").Path("/admin").Domain("admin.example.com").Clear()
+		}, nil, []string{"a=; Path=/admin; Domain=admin.example.com; " + expired + "; SameSite=Lax"}},
+		{"builder SameSite=None without Secure", Config{}, func(c *Context) {
+			if err := c.Cookie("a").SameSite(http.SameSiteNoneMode).Clear(); err != ErrInsecureCookie {
+				t.Errorf("Clear = %v, want ErrInsecureCookie", err)
+			}
+		}, nil, nil},
+	}
+	for _, tt := range tests {
+		got := setCookies(t, tt.config, func(c *Context) error {
+			tt.clear(c)
+			return nil
+		}, tt.headers...)
+		if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+			t.Errorf("%s: Set-Cookie = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSetRequestCookies(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(HeaderCookie, "a=1; b=2")
+	c := NewContext(httptest.NewRecorder(), req)
+	if cookie, err := c.GetCookie("a"); err != nil || cookie.Value != "1" {
+		t.Fatalf("GetCookie = %v, %v", cookie, err)
+	}
+	c.SetRequestCookies([]*http.Cookie{{Name: "a", Value: `{"x":"y;z"}`}})
+	if cookie, err := c.GetCookie("a"); err != nil || cookie.Value != `{"x":"y;z"}` {
+		t.Errorf("GetCookie = %v, %v", cookie, err)
+	}
+	if _, err := c.GetCookie("b"); err != http.ErrNoCookie {
+		t.Errorf("GetCookie b = %v, want http.ErrNoCookie", err)
+	}
+	if got := c.GetCookies(); len(got) != 1 || got[0].Name != "a" {
+		t.Errorf("GetCookies = %v", got)
+	}
+	if got := req.Header.Get(HeaderCookie); got != "a=1; b=2" {
+		t.Errorf("Cookie header = %q", got)
+	}
+	c.SetRequestCookies(nil)
+	if got := c.GetCookies(); len(got) != 0 {
+		t.Errorf("GetCookies after SetRequestCookies(nil) = %v", got)
+	}
+}
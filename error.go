@@ -1,16 +1,53 @@
 package seng
 
-// Error Built-in error
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+)
+
+// Problem details content types, RFC 7807
+const (
+	MIMEApplicationProblemJSON = "application/problem+json"
+	MIMEApplicationProblemXML  = "application/problem+xml"
+)
+
+// problemNamespace of problem+xml documents
+const problemNamespace = "urn:ietf:rfc:7807"
+
+// Error Built-in error, rendered as RFC 7807 problem details by ProblemErrorHandler
 type Error struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
+	// Type URI identifying the problem type, "about:blank" when empty
+	Type string `json:"type,omitempty"`
+	// Title short summary of the problem type, the status text when empty
+	Title string `json:"title,omitempty"`
+	// Detail explanation of this occurrence, Message when empty
+	Detail string `json:"detail,omitempty"`
+	// Instance URI identifying this occurrence
+	Instance string `json:"instance,omitempty"`
+	// Extensions additional members of the problem
+	Extensions map[string]interface{} `json:"-"`
+	// Internal cause, matched by errors.Is and errors.As but never sent to clients
+	Internal error `json:"-"`
+	// Headers set on the error response, e.g. Retry-After
+	Headers http.Header `json:"-"`
 }
 
-// Error implements error interface
+// Error implements error interface, the internal error is not included
 func (e *Error) Error() string {
 	return e.Message
 }
 
+// Unwrap returns the internal error
+func (e *Error) Unwrap() error {
+	return e.Internal
+}
+
 func NewError(code int, message ...string) *Error {
 	e := &Error{
 		Code: code,
@@ -22,3 +59,214 @@ func NewError(code int, message ...string) *Error {
 	}
 	return e
 }
+
+// clone copies e so that shared errors are not modified by With methods
+func (e *Error) clone() *Error {
+	c := *e
+	if e.Extensions != nil {
+		c.Extensions = make(map[string]interface{}, len(e.Extensions))
+		for key, value := range e.Extensions {
+			c.Extensions[key] = value
+		}
+	}
+	c.Headers = e.Headers.Clone()
+	return &c
+}
+
+// WithInternal returns a copy of e wrapping err
+// return seng.NewError(http.StatusBadGateway).WithInternal(err)
+func (e *Error) WithInternal(err error) *Error {
+	c := e.clone()
+	c.Internal = err
+	return c
+}
+
+// WithType returns a copy of e with the problem type URI
+func (e *Error) WithType(typ string) *Error {
+	c := e.clone()
+	c.Type = typ
+	return c
+}
+
+// WithTitle returns a copy of e with the problem title
+func (e *Error) WithTitle(title string) *Error {
+	c := e.clone()
+	c.Title = title
+	return c
+}
+
+// WithDetail returns a copy of e with the problem detail
+func (e *Error) WithDetail(detail string) *Error {
+	c := e.clone()
+	c.Detail = detail
+	return c
+}
+
+// WithInstance returns a copy of e with the problem instance URI
+func (e *Error) WithInstance(instance string) *Error {
+	c := e.clone()
+	c.Instance = instance
+	return c
+}
+
+// With returns a copy of e with the extension member key
+// seng.NewError(http.StatusForbidden).With("balance", 30)
+func (e *Error) With(key string, value interface{}) *Error {
+	c := e.clone()
+	if c.Extensions == nil {
+		c.Extensions = make(map[string]interface{})
+	}
+	c.Extensions[key] = value
+	return c
+}
+
+// WithHeader returns a copy of e which sets the response header key
+// seng.NewError(http.StatusTooManyRequests).WithHeader("Retry-After", "120")
+func (e *Error) WithHeader(key, value string) *Error {
+	c := e.clone()
+	if c.Headers == nil {
+		c.Headers = make(http.Header)
+	}
+	c.Headers.Set(key, value)
+	return c
+}
+
+// Problem returns the problem details of e, defaults are filled in
+func (e *Error) Problem() Problem {
+	p := Problem{
+		Type:       e.Type,
+		Title:      e.Title,
+		Status:     e.Code,
+		Detail:     e.Detail,
+		Instance:   e.Instance,
+		Extensions: e.Extensions,
+	}
+	if p.Type == "" {
+		p.Type = "about:blank"
+	}
+	if p.Title == "" {
+		p.Title = StatusMessage(e.Code)
+	}
+	if p.Detail == "" && e.Message != p.Title {
+		p.Detail = e.Message
+	}
+	return p
+}
+
+// Problem RFC 7807 problem details, extension members are top-level members
+type Problem struct {
+	Type       string
+	Title      string
+	Status     int
+	Detail     string
+	Instance   string
+	Extensions map[string]interface{}
+}
+
+// problemMembers standard members
+var problemMembers = map[string]bool{
+	"type": true, "title": true, "status": true, "detail": true, "instance": true,
+}
+
+// MarshalJSON implements json.Marshaler
+func (p Problem) MarshalJSON() ([]byte, error) {
+	members := make(map[string]interface{}, len(p.Extensions)+5)
+	for key, value := range p.Extensions {
+		if !problemMembers[key] {
+			members[key] = value
+		}
+	}
+	members["type"] = p.Type
+	members["title"] = p.Title
+	members["status"] = p.Status
+	if p.Detail != "" {
+		members["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		members["instance"] = p.Instance
+	}
+	return json.Marshal(members)
+}
+
+// problemMember an element of a problem+xml document
+type problemMember struct {
+	name  string
+	value interface{}
+}
+
+// MarshalXML implements xml.Marshaler as in RFC 7807 appendix A
+func (p Problem) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	start := xml.StartElement{Name: xml.Name{Space: problemNamespace, Local: "problem"}}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	members := []problemMember{
+		{"type", p.Type},
+		{"title", p.Title},
+		{"status", p.Status},
+	}
+	if p.Detail != "" {
+		members = append(members, problemMember{"detail", p.Detail})
+	}
+	if p.Instance != "" {
+		members = append(members, problemMember{"instance", p.Instance})
+	}
+	for _, member := range members {
+		if err := e.EncodeElement(member.value, xml.StartElement{Name: xml.Name{Local: member.name}}); err != nil {
+			return err
+		}
+	}
+	keys := make([]string, 0, len(p.Extensions))
+	for key := range p.Extensions {
+		if !problemMembers[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := p.Extensions[key]
+		// encoding/xml does not support maps
+		if v := reflect.ValueOf(value); v.Kind() == reflect.Map {
+			value = fmt.Sprint(value)
+		}
+		if err := e.EncodeElement(value, xml.StartElement{Name: xml.Name{Local: key}}); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// ProblemErrorHandler renders errors as application/problem+json or
+// application/problem+xml by the Accept header.
+// Errors are resolved with Engine.ResolveError, unmapped errors are 500
+// Internal Server Error without details.
+// seng.New(seng.Config{ErrorHandler: seng.ProblemErrorHandler})
+var ProblemErrorHandler = func(c *Context, err error) error {
+	e := c.engine.ResolveError(err)
+	setErrorHeaders(c, e)
+	problem := e.Problem()
+	if problem.Instance == "" {
+		problem.Instance = c.Path
+	}
+	c.Writer.Header().Add(HeaderVary, HeaderAccept)
+	switch c.Accepts(MIMEApplicationProblemJSON, ContentTypeJson, MIMEApplicationProblemXML, ContentTypeXml) {
+	case MIMEApplicationProblemXML, ContentTypeXml:
+		body, err := xml.Marshal(problem)
+		if err != nil {
+			return err
+		}
+		return c.Status(e.Code).writeContent(MIMEApplicationProblemXML+CharsetSuffix, append([]byte(xml.Header), body...))
+	}
+	body, err := json.Marshal(problem)
+	if err != nil {
+		return err
+	}
+	return c.Status(e.Code).writeContent(MIMEApplicationProblemJSON+CharsetSuffix, body)
+}
+
+// setErrorHeaders sets the Headers of e on the response
+func setErrorHeaders(c *Context, e *Error) {
+	for key, values := range e.Headers {
+		c.Writer.Header()[key] = values
+	}
+}
@@ -0,0 +1,229 @@
+package seng
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrorMapper converts err to an *Error, or returns nil if it does not handle err
+type ErrorMapper func(err error) *Error
+
+// MapError maps errors matching target with errors.Is to code, the message is
+// the status text by default
+// e.MapError(sql.ErrNoRows, http.StatusNotFound)
+func (e *Engine) MapError(target error, code int, message ...string) {
+	e.MapErrorFunc(func(err error) *Error {
+		if errors.Is(err, target) {
+			return NewError(code, message...)
+		}
+		return nil
+	})
+}
+
+// MapErrorType maps errors of the type of target, found with errors.As, to code
+// e.MapErrorType(&os.PathError{}, http.StatusNotFound)
+func (e *Engine) MapErrorType(target error, code int, message ...string) {
+	typ := reflect.TypeOf(target)
+	if typ == nil {
+		panic("seng: MapErrorType target must not be nil")
+	}
+	e.MapErrorFunc(func(err error) *Error {
+		if errors.As(err, reflect.New(typ).Interface()) {
+			return NewError(code, message...)
+		}
+		return nil
+	})
+}
+
+// MapErrorFunc adds a mapper, mappers are tried in registration order
+func (e *Engine) MapErrorFunc(mapper ErrorMapper) {
+	e.errorMappers = append(e.errorMappers, mapper)
+}
+
+// ResolveError returns the *Error in the wrap chain of err, else the result
+// of the first matching mapper wrapping err, else a 400 Bad Request with the
+// "errors" member for ValidationErrors and *FieldError, else a 500 Internal
+// Server Error wrapping err
+func (e *Engine) ResolveError(err error) *Error {
+	var httpErr *Error
+	if errors.As(err, &httpErr) {
+		return httpErr
+	}
+	// contexts without engine have no mappers
+	if e != nil {
+		for _, mapper := range e.errorMappers {
+			if mapped := mapper(err); mapped != nil {
+				if mapped.Internal == nil {
+					mapped = mapped.WithInternal(err)
+				}
+				return mapped
+			}
+		}
+	}
+	var validationErrs ValidationErrors
+	if errors.As(err, &validationErrs) {
+		return validationError(validationErrs).WithInternal(err)
+	}
+	var fieldErr *FieldError
+	if errors.As(err, &fieldErr) {
+		return validationError(ValidationErrors{fieldErr}).WithInternal(err)
+	}
+	return NewError(http.StatusInternalServerError).WithInternal(err)
+}
+
+// OnError adds error handlers to the group. Errors are passed to the handlers
+// of the innermost matching group first, then to its parents and finally to
+// Config.ErrorHandler. A handler returns nil when it handled the error, or an
+// error for the next handler.
+//
+//	api.OnError(func(c *seng.Context, err error) error {
+//		if errors.Is(err, ErrQuota) {
+//			return c.Status(http.StatusTooManyRequests).JSON(seng.Map{"error": "quota"})
+//		}
+//		return err
+//	})
+func (g *RouterGroup) OnError(handlers ...ErrorHandler) {
+	g.errorHandlers = append(g.errorHandlers, handlers...)
+}
+
+// matchGroups returns the groups matching path, the longest prefix first
+func (e *Engine) matchGroups(path string) []*RouterGroup {
+	var groups []*RouterGroup
+	for _, group := range e.groups {
+		if hasPathPrefix(path, group.prefix) {
+			groups = append(groups, group)
+		}
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		return len(groups[i].prefix) > len(groups[j].prefix)
+	})
+	return groups
+}
+
+// hasPathPrefix reports whether path is in prefix by whole segments, /api
+// matches /api and /api/users but not /apiv2
+func hasPathPrefix(path, prefix string) bool {
+	if prefix == "" || path == prefix {
+		return true
+	}
+	if !strings.HasPrefix(path, prefix) {
+		return false
+	}
+	return strings.HasSuffix(prefix, "/") || path[len(prefix)] == '/'
+}
+
+// SetErrorHandler replaces Config.ErrorHandler for requests whose path
+// matches the group, the longest matching group wins
+func (g *RouterGroup) SetErrorHandler(handler ErrorHandler) {
+	g.errorHandler = handler
+}
+
+// SetNotFoundHandler replaces Config.NotFoundErrorHandler for requests whose
+// path matches the group, the longest matching group wins
+func (g *RouterGroup) SetNotFoundHandler(handler Handler) {
+	g.notFoundHandler = handler
+}
+
+// handleError passes err along the error handler chain of the request, then
+// to the ErrorHandler of the longest matching group or Config.ErrorHandler
+func (e *Engine) handleError(c *Context, err error) {
+	groups := e.matchGroups(c.Path)
+	for _, group := range groups {
+		for _, handler := range group.errorHandlers {
+			if err = handler(c, err); err == nil {
+				return
+			}
+		}
+	}
+	errorHandler := e.config.ErrorHandler
+	for _, group := range groups {
+		if group.errorHandler != nil {
+			errorHandler = group.errorHandler
+			break
+		}
+	}
+	if err = errorHandler(c, err); err != nil {
+		e.Logger.Printf("seng: error handler: %v", err)
+	}
+}
+
+// notFoundHandler returns the NotFound handler of the longest matching group
+// or Config.NotFoundErrorHandler
+func (e *Engine) notFoundHandler(path string) Handler {
+	for _, group := range e.matchGroups(path) {
+		if group.notFoundHandler != nil {
+			return group.notFoundHandler
+		}
+	}
+	return e.config.NotFoundErrorHandler
+}
+
+// ErrorPage is the data of the templates of HTMLErrorHandler
+type ErrorPage struct {
+	Code    int
+	Title   string
+	Message string
+	Path    string
+	Error   *Error
+}
+
+// HTMLErrorHandler renders errors with the engine templates, looking up
+// "errors/404.html", then "errors/4xx.html", then "errors/error.html" with
+// an ErrorPage. With Config.Views the names are "errors/404", "errors/4xx"
+// and "errors/error". Without template it falls back to DefaultErrorHandler.
+//
+//	{{define "errors/404.html"}}<h1>{{.Title}}</h1><p>{{.Path}} does not exist</p>{{end}}
+var HTMLErrorHandler = func(c *Context, err error) error {
+	if c.engine == nil {
+		return DefaultErrorHandler(c, err)
+	}
+	e := c.engine.ResolveError(err)
+	page := ErrorPage{
+		Code:    e.Code,
+		Title:   StatusMessage(e.Code),
+		Message: e.Message,
+		Path:    c.Path,
+		Error:   e,
+	}
+	code := strconv.Itoa(e.Code)
+	names := []string{"errors/" + code, "errors/" + code[:1] + "xx", "errors/error"}
+	if c.engine.config.Views != nil {
+		for _, name := range names {
+			buf := new(bytes.Buffer)
+			renderErr := c.renderHTML(buf, name, page)
+			if errors.Is(renderErr, ErrViewNotFound) {
+				continue
+			}
+			if renderErr != nil {
+				c.engine.Logger.Printf("seng: error page %s: %v", name, renderErr)
+				break
+			}
+			setErrorHeaders(c, e)
+			return c.Status(e.Code).writeContent(MINETextHTML, buf.Bytes())
+		}
+		return DefaultErrorHandler(c, err)
+	}
+	templates, loadErr := c.engine.templates()
+	if loadErr != nil {
+		c.engine.Logger.Printf("seng: error page: %v", loadErr)
+	}
+	for _, name := range names {
+		name += ".html"
+		if templates == nil || templates.Lookup(name) == nil {
+			continue
+		}
+		buf := new(bytes.Buffer)
+		if execErr := c.renderHTML(buf, name, page); execErr != nil {
+			c.engine.Logger.Printf("seng: error page %s: %v", name, execErr)
+			break
+		}
+		setErrorHeaders(c, e)
+		return c.Status(e.Code).writeContent(MINETextHTML, buf.Bytes())
+	}
+	return DefaultErrorHandler(c, err)
+}
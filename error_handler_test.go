@@ -0,0 +1,230 @@
+package seng
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var errQuota = errors.New("quota exceeded")
+
+func TestResolveError(t *testing.T) {
+	e := New()
+	e.MapError(io.EOF, http.StatusBadRequest, "empty body")
+	e.MapErrorType(&os.PathError{}, http.StatusNotFound)
+	e.MapErrorFunc(func(err error) *Error {
+		if errors.Is(err, errQuota) {
+			return NewError(http.StatusTooManyRequests).WithHeader("Retry-After", "60")
+		}
+		return nil
+	})
+	// shadowed by the first mapper
+	e.MapError(io.EOF, http.StatusTeapot)
+
+	fieldErr := &FieldError{Field: "name", Rule: "required", Message: "name is required"}
+	validationErrs := ValidationErrors{fieldErr, {Field: "age", Rule: "min", Param: "1", Message: "age must be at least 1"}}
+	httpErr := NewError(http.StatusConflict, "taken")
+	tests := []struct {
+		name        string
+		err         error
+		wantCode    int
+		wantMessage string
+		wantErrors  ValidationErrors
+	}{
+		{"error", httpErr, http.StatusConflict, "taken", nil},
+		{"wrapped error", fmt.Errorf("create: %w", httpErr), http.StatusConflict, "taken", nil},
+		{"mapped", fmt.Errorf("read: %w", io.EOF), http.StatusBadRequest, "empty body", nil},
+		{"mapped type", &os.PathError{Op: "open", Path: "x", Err: os.ErrNotExist}, http.StatusNotFound, "Not Found", nil},
+		{"mapped func", errQuota, http.StatusTooManyRequests, "Too Many Requests", nil},
+		{"validation errors", validationErrs, http.StatusBadRequest, validationErrs.Error(), validationErrs},
+		{"wrapped validation errors", fmt.Errorf("signup: %w", validationErrs), http.StatusBadRequest, validationErrs.Error(), validationErrs},
+		{"field error", fieldErr, http.StatusBadRequest, "name is required", ValidationErrors{fieldErr}},
+		{"unmapped", errors.New("boom"), http.StatusInternalServerError, "Internal Server Error", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := e.ResolveError(tt.err)
+			if got.Code != tt.wantCode || got.Message != tt.wantMessage {
+				t.Errorf("ResolveError = %d %q, want %d %q", got.Code, got.Message, tt.wantCode, tt.wantMessage)
+			}
+			if got != httpErr && !reflect.DeepEqual(got.Internal, tt.err) {
+				t.Errorf("ResolveError does not wrap %v", tt.err)
+			}
+			if tt.wantErrors != nil && !reflect.DeepEqual(got.Extensions["errors"], tt.wantErrors) {
+				t.Errorf("errors = %v, want %v", got.Extensions["errors"], tt.wantErrors)
+			}
+		})
+	}
+	if got := e.ResolveError(errQuota); got.Headers.Get("Retry-After") != "60" {
+		t.Errorf("Retry-After = %q", got.Headers.Get("Retry-After"))
+	}
+}
+
+func TestResolveValidationErrorMapped(t *testing.T) {
+	e := New()
+	e.MapErrorType(ValidationErrors{}, http.StatusUnprocessableEntity)
+	if got := e.ResolveError(ValidationErrors{{Field: "a"}}); got.Code != http.StatusUnprocessableEntity {
+		t.Errorf("status = %d, mappers must take precedence", got.Code)
+	}
+}
+
+func TestValidateProblem(t *testing.T) {
+	type signup struct {
+		Name string `json:"name" validate:"required"`
+		Age  int    `json:"age" validate:"min=18"`
+	}
+	tests := []struct {
+		name       string
+		body       string
+		wantCode   int
+		wantFields []string
+	}{
+		{"valid", `{"name":"a","age":20}`, http.StatusOK, nil},
+		{"one field", `{"name":"a","age":3}`, http.StatusBadRequest, []string{"age"}},
+		{"all fields", `{}`, http.StatusBadRequest, []string{"name", "age"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New(Config{ErrorHandler: ProblemErrorHandler})
+			e.POST("/signup", func(c *Context) error {
+				var req signup
+				if err := c.BodyParser(&req); err != nil {
+					return err
+				}
+				if err := c.Validate(&req); err != nil {
+					return err
+				}
+				return c.NoContent()
+			})
+			w := serve(t, e, http.MethodPost, "/signup", tt.body, HeaderContentType, ContentTypeJson)
+			if tt.wantCode == http.StatusOK {
+				if w.Code != http.StatusNoContent {
+					t.Errorf("status = %d, body %s", w.Code, w.Body)
+				}
+				return
+			}
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantCode)
+			}
+			var problem struct {
+				Status int          `json:"status"`
+				Errors []FieldError `json:"errors"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+				t.Fatal(err)
+			}
+			var fields []string
+			for _, fieldErr := range problem.Errors {
+				fields = append(fields, fieldErr.Field)
+			}
+			if problem.Status != tt.wantCode || !reflect.DeepEqual(fields, tt.wantFields) {
+				t.Errorf("problem = %+v, want fields %v", problem, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestOnError(t *testing.T) {
+	e := New()
+	var calls []string
+	handler := func(name string, handle error) ErrorHandler {
+		return func(c *Context, err error) error {
+			calls = append(calls, name)
+			if errors.Is(err, handle) {
+				return c.Status(http.StatusTooManyRequests).Text(name)
+			}
+			return err
+		}
+	}
+	errInner := errors.New("inner")
+	api := e.Group("/api")
+	api.OnError(handler("api", errQuota))
+	v1 := api.Group("/v1")
+	v1.OnError(handler("v1", errInner), handler("v1 second", errInner))
+	fail := func(err error) Handler {
+		return func(c *Context) error { return err }
+	}
+	v1.GET("/quota", fail(errQuota))
+	v1.GET("/inner", fail(errInner))
+	v1.GET("/other", fail(io.EOF))
+	e.GET("/quota", fail(errQuota))
+	e.Group("/apiv2").GET("/quota", fail(errQuota))
+
+	tests := []struct {
+		path      string
+		wantCode  int
+		wantBody  string
+		wantCalls []string
+	}{
+		{"/api/v1/inner", http.StatusTooManyRequests, "v1", []string{"v1"}},
+		{"/api/v1/quota", http.StatusTooManyRequests, "api", []string{"v1", "v1 second", "api"}},
+		{"/api/v1/other", http.StatusInternalServerError, "Internal Server Error", []string{"v1", "v1 second", "api"}},
+		{"/quota", http.StatusInternalServerError, "Internal Server Error", nil},
+		// /apiv2 is not in /api
+		{"/apiv2/quota", http.StatusInternalServerError, "Internal Server Error", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			calls = nil
+			w := serve(t, e, http.MethodGet, tt.path, "")
+			if w.Code != tt.wantCode || w.Body.String() != tt.wantBody {
+				t.Errorf("response = %d %q, want %d %q", w.Code, w.Body, tt.wantCode, tt.wantBody)
+			}
+			if !reflect.DeepEqual(calls, tt.wantCalls) {
+				t.Errorf("calls = %v, want %v", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestHasPathPrefix(t *testing.T) {
+	tests := []struct {
+		path   string
+		prefix string
+		want   bool
+	}{
+		{"/api", "", true},
+		{"/api", "/api", true},
+		{"/api/users", "/api", true},
+		{"/apiv2", "/api", false},
+		{"/apiv2/users", "/api", false},
+		{"/ap", "/api", false},
+		{"/api/users", "/api/", true},
+		{"/", "/", true},
+		{"/users", "/", true},
+	}
+	for _, tt := range tests {
+		if got := hasPathPrefix(tt.path, tt.prefix); got != tt.want {
+			t.Errorf("hasPathPrefix(%q, %q) = %v, want %v", tt.path, tt.prefix, got, tt.want)
+		}
+	}
+}
+
+func TestErrorHandlersWithoutEngine(t *testing.T) {
+	handlers := []struct {
+		name     string
+		handler  ErrorHandler
+		wantType string
+	}{
+		{"default", DefaultErrorHandler, MIMETextPlainCharsetUTF8},
+		{"problem", ProblemErrorHandler, MIMEApplicationProblemJSON + CharsetSuffix},
+		{"html", HTMLErrorHandler, MIMETextPlainCharsetUTF8},
+	}
+	for _, tt := range handlers {
+		w := httptest.NewRecorder()
+		c := NewContext(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		if err := tt.handler(c, NewError(http.StatusConflict, "taken")); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if w.Code != http.StatusConflict || w.Header().Get(HeaderContentType) != tt.wantType || !strings.Contains(w.Body.String(), "taken") {
+			t.Errorf("%s: %d %q %q", tt.name, w.Code, w.Header().Get(HeaderContentType), w.Body)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package seng
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestGroupHandlers(t *testing.T) {
+	e := New()
+	api := e.Group("/api")
+	api.SetErrorHandler(ProblemErrorHandler)
+	api.SetNotFoundHandler(func(c *Context) error {
+		return NewError(http.StatusNotFound, "unknown endpoint")
+	})
+	admin := api.Group("/admin")
+	admin.SetErrorHandler(func(c *Context, err error) error {
+		return c.Status(c.Engine().ResolveError(err).Code).Text("admin: %v", err)
+	})
+	fail := func(c *Context) error {
+		return NewError(http.StatusConflict, "taken")
+	}
+	api.GET("/pets", fail)
+	admin.GET("/pets", fail)
+	e.GET("/pets", fail)
+	// a sibling of /api, not in the group
+	e.Group("/apiv2").GET("/pets", fail)
+
+	tests := []struct {
+		path     string
+		wantCode int
+		wantType string
+		wantBody string
+	}{
+		{"/pets", http.StatusConflict, MIMETextPlainCharsetUTF8, "taken"},
+		{"/missing", http.StatusNotFound, MIMETextPlainCharsetUTF8, "404 not found"},
+		{"/api/pets", http.StatusConflict, MIMEApplicationProblemJSON + CharsetSuffix, `"detail":"taken"`},
+		{"/api/missing", http.StatusNotFound, MIMEApplicationProblemJSON + CharsetSuffix, `"detail":"unknown endpoint"`},
+		{"/api/admin/pets", http.StatusConflict, MIMETextPlainCharsetUTF8, "admin: taken"},
+		// the not found handler of /api with the error handler of /api/admin
+		{"/api/admin/missing", http.StatusNotFound, MIMETextPlainCharsetUTF8, "admin: unknown endpoint"},
+		{"/apiv2/pets", http.StatusConflict, MIMETextPlainCharsetUTF8, "taken"},
+		{"/apiv2/missing", http.StatusNotFound, MIMETextPlainCharsetUTF8, "404 not found"},
+		{"/apiv2", http.StatusNotFound, MIMETextPlainCharsetUTF8, "404 not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := serve(t, e, http.MethodGet, tt.path, "")
+			if w.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantCode)
+			}
+			if got := w.Header().Get(HeaderContentType); got != tt.wantType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantType)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body = %q, want %q", w.Body, tt.wantBody)
+			}
+		})
+	}
+}
+
+// errorPagesFS templates of HTMLErrorHandler, in views and in LoadHTMLFS form
+var errorPagesFS = fstest.MapFS{
+	"errors/404.html":   {Data: []byte(`{{define "errors/404.html"}}missing {{.Path}}{{end}}`)},
+	"errors/5xx.html":   {Data: []byte(`{{define "errors/5xx.html"}}{{.Code}} {{.Title}}{{end}}`)},
+	"errors/error.html": {Data: []byte(`{{define "errors/error.html"}}error {{.Code}}: {{.Message}}{{end}}`)},
+}
+
+// errorViewsFS the templates of errorPagesFS for HTMLViews
+var errorViewsFS = fstest.MapFS{
+	"errors/404.html":   {Data: []byte(`missing {{.Path}}`)},
+	"errors/5xx.html":   {Data: []byte(`{{.Code}} {{.Title}}`)},
+	"errors/error.html": {Data: []byte(`error {{.Code}}: {{.Message}}`)},
+}
+
+func TestHTMLErrorHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		err      error
+		wantCode int
+		wantHTML bool
+		wantBody string
+	}{
+		{"exact code", "/missing", nil, http.StatusNotFound, true, "missing /missing"},
+		{"class", "/fail", NewError(http.StatusBadGateway), http.StatusBadGateway, true, "502 Bad Gateway"},
+		{"generic", "/fail", NewError(http.StatusConflict, "taken"), http.StatusConflict, true, "error 409: taken"},
+		{"escaped", "/fail", NewError(http.StatusConflict, "<b>"), http.StatusConflict, true, "error 409: &lt;b&gt;"},
+		{"headers", "/fail", NewError(http.StatusServiceUnavailable).WithHeader("Retry-After", "5"), http.StatusServiceUnavailable, true, "503 Service Unavailable"},
+	}
+	setups := []struct {
+		name  string
+		setup func(t *testing.T) *Engine
+	}{
+		{"templates", func(t *testing.T) *Engine {
+			e := New(Config{ErrorHandler: HTMLErrorHandler})
+			if err := e.LoadHTMLFS(errorPagesFS, "errors/*.html"); err != nil {
+				t.Fatal(err)
+			}
+			return e
+		}},
+		{"views", func(t *testing.T) *Engine {
+			return New(Config{ErrorHandler: HTMLErrorHandler, Views: NewHTMLViewsFS(errorViewsFS, ".html")})
+		}},
+	}
+	for _, setup := range setups {
+		for _, tt := range tests {
+			t.Run(setup.name+"/"+tt.name, func(t *testing.T) {
+				e := setup.setup(t)
+				e.GET("/fail", func(c *Context) error { return tt.err })
+				w := serve(t, e, http.MethodGet, tt.path, "")
+				if w.Code != tt.wantCode {
+					t.Errorf("status = %d, want %d", w.Code, tt.wantCode)
+				}
+				if got := w.Header().Get(HeaderContentType); got != MINETextHTML {
+					t.Errorf("Content-Type = %q", got)
+				}
+				if w.Body.String() != tt.wantBody {
+					t.Errorf("body = %q, want %q", w.Body, tt.wantBody)
+				}
+				if tt.name == "headers" && w.Header().Get("Retry-After") != "5" {
+					t.Errorf("Retry-After = %q", w.Header().Get("Retry-After"))
+				}
+			})
+		}
+	}
+}
+
+func TestHTMLErrorHandlerFallback(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(t *testing.T) *Engine
+	}{
+		{"no templates", func(t *testing.T) *Engine {
+			return New(Config{ErrorHandler: HTMLErrorHandler})
+		}},
+		{"no error templates", func(t *testing.T) *Engine {
+			e := New(Config{ErrorHandler: HTMLErrorHandler})
+			if err := e.LoadHTMLFS(fstest.MapFS{"index.html": {Data: []byte("index")}}, "*.html"); err != nil {
+				t.Fatal(err)
+			}
+			return e
+		}},
+		{"broken template", func(t *testing.T) *Engine {
+			e := New(Config{ErrorHandler: HTMLErrorHandler})
+			broken := fstest.MapFS{"errors/error.html": {Data: []byte(`{{define "errors/error.html"}}{{.Missing}}{{end}}`)}}
+			if err := e.LoadHTMLFS(broken, "errors/*.html"); err != nil {
+				t.Fatal(err)
+			}
+			return e
+		}},
+		{"no error views", func(t *testing.T) *Engine {
+			return New(Config{ErrorHandler: HTMLErrorHandler, Views: NewHTMLViewsFS(fstest.MapFS{"index.html": {Data: []byte("index")}}, ".html")})
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := tt.setup(t)
+			e.GET("/fail", func(c *Context) error { return NewError(http.StatusConflict, "taken") })
+			w := serve(t, e, http.MethodGet, "/fail", "")
+			if w.Code != http.StatusConflict || w.Body.String() != "taken" {
+				t.Errorf("response = %d %q, want DefaultErrorHandler output", w.Code, w.Body)
+			}
+			if got := w.Header().Get(HeaderContentType); got != MIMETextPlainCharsetUTF8 {
+				t.Errorf("Content-Type = %q", got)
+			}
+		})
+	}
+}
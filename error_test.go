@@ -0,0 +1,161 @@
+package seng
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestErrorWith(t *testing.T) {
+	base := NewError(http.StatusForbidden).With("balance", 30).WithHeader("X-Reason", "funds")
+	cause := io.ErrUnexpectedEOF
+	derived := base.
+		With("accounts", []string{"a"}).
+		WithHeader("Retry-After", "120").
+		WithType("https://example.com/probs/out-of-credit").
+		WithTitle("Out of credit").
+		WithDetail("Your balance is 30").
+		WithInstance("/account/1").
+		WithInternal(cause)
+
+	if len(base.Extensions) != 1 || len(base.Headers) != 1 || base.Type != "" || base.Internal != nil {
+		t.Errorf("base modified: %+v", base)
+	}
+	want := &Error{
+		Code:       http.StatusForbidden,
+		Message:    StatusMessage(http.StatusForbidden),
+		Type:       "https://example.com/probs/out-of-credit",
+		Title:      "Out of credit",
+		Detail:     "Your balance is 30",
+		Instance:   "/account/1",
+		Extensions: map[string]interface{}{"balance": 30, "accounts": []string{"a"}},
+		Internal:   cause,
+		Headers:    http.Header{"X-Reason": {"funds"}, "Retry-After": {"120"}},
+	}
+	if !reflect.DeepEqual(derived, want) {
+		t.Errorf("derived = %+v, want %+v", derived, want)
+	}
+	if !errors.Is(derived, io.ErrUnexpectedEOF) {
+		t.Error("errors.Is does not find the internal error")
+	}
+	if derived.Error() != StatusMessage(http.StatusForbidden) {
+		t.Errorf("Error() = %q, the internal error must not be included", derived.Error())
+	}
+}
+
+func TestErrorProblem(t *testing.T) {
+	tests := []struct {
+		name string
+		err  *Error
+		want Problem
+	}{
+		{"defaults", NewError(http.StatusNotFound),
+			Problem{Type: "about:blank", Title: StatusMessage(http.StatusNotFound), Status: http.StatusNotFound}},
+		{"message is detail", NewError(http.StatusNotFound, "no such pet"),
+			Problem{Type: "about:blank", Title: StatusMessage(http.StatusNotFound), Status: http.StatusNotFound, Detail: "no such pet"}},
+		{"explicit detail", NewError(http.StatusNotFound, "no such pet").WithDetail("pet 7").WithTitle("Missing"),
+			Problem{Type: "about:blank", Title: "Missing", Status: http.StatusNotFound, Detail: "pet 7"}},
+		{"extensions", NewError(http.StatusConflict).WithType("urn:conflict").WithInstance("/pets/7").With("id", 7),
+			Problem{Type: "urn:conflict", Title: StatusMessage(http.StatusConflict), Status: http.StatusConflict,
+				Instance: "/pets/7", Extensions: map[string]interface{}{"id": 7}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Problem(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Problem = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProblemMarshal(t *testing.T) {
+	tests := []struct {
+		name     string
+		problem  Problem
+		wantJSON string
+		wantXML  string
+	}{
+		{"minimal", Problem{Type: "about:blank", Title: "Not Found", Status: 404},
+			`{"status":404,"title":"Not Found","type":"about:blank"}`,
+			`<problem xmlns="urn:ietf:rfc:7807"><type>about:blank</type><title>Not Found</title><status>404</status></problem>`},
+		{"all members", Problem{Type: "urn:credit", Title: "Out of credit", Status: 403, Detail: "balance 30", Instance: "/a/1",
+			Extensions: map[string]interface{}{"balance": 30, "status": 200, "limits": map[string]int{"day": 1}}},
+			`{"balance":30,"detail":"balance 30","instance":"/a/1","limits":{"day":1},"status":403,"title":"Out of credit","type":"urn:credit"}`,
+			`<problem xmlns="urn:ietf:rfc:7807"><type>urn:credit</type><title>Out of credit</title><status>403</status>` +
+				`<detail>balance 30</detail><instance>/a/1</instance><balance>30</balance><limits>map[day:1]</limits></problem>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := json.Marshal(tt.problem)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(body) != tt.wantJSON {
+				t.Errorf("JSON = %s, want %s", body, tt.wantJSON)
+			}
+			if body, err = xml.Marshal(tt.problem); err != nil {
+				t.Fatal(err)
+			}
+			if string(body) != tt.wantXML {
+				t.Errorf("XML = %s, want %s", body, tt.wantXML)
+			}
+		})
+	}
+}
+
+func TestProblemErrorHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		accept   string
+		wantCode int
+		wantType string
+		wantBody string
+	}{
+		{"json", NewError(http.StatusNotFound, "no such pet"), "", http.StatusNotFound, MIMEApplicationProblemJSON,
+			`{"detail":"no such pet","instance":"/pets/7","status":404,"title":"Not Found","type":"about:blank"}`},
+		{"problem json", NewError(http.StatusConflict).With("id", 7).WithInstance("/x"), MIMEApplicationProblemJSON, http.StatusConflict, MIMEApplicationProblemJSON,
+			`{"id":7,"instance":"/x","status":409,"title":"Conflict","type":"about:blank"}`},
+		{"xml", NewError(http.StatusNotFound), ContentTypeXml, http.StatusNotFound, MIMEApplicationProblemXML,
+			xml.Header + `<problem xmlns="urn:ietf:rfc:7807"><type>about:blank</type><title>Not Found</title><status>404</status><instance>/pets/7</instance></problem>`},
+		{"problem xml preferred", NewError(http.StatusNotFound), "application/problem+xml, application/json;q=0.5", http.StatusNotFound, MIMEApplicationProblemXML, ""},
+		{"html falls back to json", NewError(http.StatusGone), "text/html", http.StatusGone, MIMEApplicationProblemJSON,
+			`{"instance":"/pets/7","status":410,"title":"Gone","type":"about:blank"}`},
+		{"internal error hidden", errors.New("db password wrong"), "", http.StatusInternalServerError, MIMEApplicationProblemJSON,
+			`{"instance":"/pets/7","status":500,"title":"Internal Server Error","type":"about:blank"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New(Config{ErrorHandler: ProblemErrorHandler})
+			e.GET("/pets/:id", func(c *Context) error {
+				return tt.err
+			})
+			w := serve(t, e, http.MethodGet, "/pets/7", "", HeaderAccept, tt.accept)
+			if w.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantCode)
+			}
+			if got := w.Header().Get(HeaderContentType); got != tt.wantType+CharsetSuffix {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantType)
+			}
+			if got := w.Header().Get(HeaderVary); !strings.Contains(got, HeaderAccept) {
+				t.Errorf("Vary = %q", got)
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("body = %s, want %s", w.Body, tt.wantBody)
+			}
+		})
+	}
+
+	e := New(Config{ErrorHandler: ProblemErrorHandler})
+	e.GET("/", func(c *Context) error {
+		return NewError(http.StatusTooManyRequests).WithHeader("Retry-After", "120")
+	})
+	if w := serve(t, e, http.MethodGet, "/", ""); w.Header().Get("Retry-After") != "120" {
+		t.Errorf("Retry-After = %q", w.Header().Get("Retry-After"))
+	}
+}
@@ -22,6 +22,7 @@ func (t *TestHandler) AddPet(writer http.ResponseWriter, request *http.Request)
 	})
 	if err != nil {
 		seng.RenderInternalServerError(writer, request, err)
+		return
 	}
 	seng.Render(writer, request, http.StatusOK, pets)
 }
@@ -0,0 +1,199 @@
+package seng
+
+import (
+	"bytes"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SendFile sends the file at path, supporting Range, If-Range,
+// If-Modified-Since and If-None-Match.
+// path is not sanitized, do not build it from user input.
+func (c *Context) SendFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fileError(err)
+	}
+	defer file.Close()
+	return c.sendFile(file, true, true)
+}
+
+// SendFS sends the file name of fsys as SendFile does
+// c.SendFS(assets, "docs/guide.pdf")
+func (c *Context) SendFS(fsys fs.FS, name string) error {
+	if !fs.ValidPath(name) {
+		return NewError(http.StatusBadRequest)
+	}
+	file, err := fsys.Open(name)
+	if err != nil {
+		return fileError(err)
+	}
+	defer file.Close()
+	return c.sendFile(file, true, true)
+}
+
+// Download sends the file at path as an attachment named name, the base of path by default
+func (c *Context) Download(path string, name ...string) error {
+	if len(name) > 0 {
+		c.Attachment(name[0])
+	} else {
+		c.Attachment(filepath.Base(path))
+	}
+	return c.SendFile(path)
+}
+
+// Inline sends the file at path to be displayed by the browser, named name
+func (c *Context) Inline(path string, name ...string) error {
+	filename := filepath.Base(path)
+	if len(name) > 0 {
+		filename = name[0]
+	}
+	c.Writer.Header().Set(HeaderContentDisposition, contentDisposition("inline", filename))
+	return c.SendFile(path)
+}
+
+// Attachment sets Content-Disposition to download the response as filename,
+// and Content-Type from its extension
+func (c *Context) Attachment(filename ...string) {
+	if len(filename) == 0 {
+		c.Writer.Header().Set(HeaderContentDisposition, "attachment")
+		return
+	}
+	c.Writer.Header().Set(HeaderContentDisposition, contentDisposition("attachment", filename[0]))
+	if contentType := mime.TypeByExtension(filepath.Ext(filename[0])); contentType != "" {
+		c.Writer.Header().Set(HeaderContentType, contentType)
+	}
+}
+
+// sendFile serves an opened file with http.ServeContent, with an ETag and
+// a Last-Modified header if etag and lastModified are set.
+// A Content-Type and Content-Encoding set before are kept.
+func (c *Context) sendFile(file fs.File, etag, lastModified bool) error {
+	stat, err := file.Stat()
+	if err != nil {
+		return fileError(err)
+	}
+	if stat.IsDir() {
+		return NewError(http.StatusNotFound)
+	}
+	content, ok := file.(io.ReadSeeker)
+	if !ok {
+		data, err := ioutil.ReadAll(file)
+		if err != nil {
+			return err
+		}
+		content = bytes.NewReader(data)
+	}
+	header := c.Writer.Header()
+	if header.Get(HeaderContentType) == "" {
+		if contentType := mime.TypeByExtension(filepath.Ext(stat.Name())); contentType != "" {
+			header.Set(HeaderContentType, contentType)
+		}
+	}
+	if etag && header.Get(HeaderETag) == "" {
+		value, err := fileETag(stat, content)
+		if err != nil {
+			return err
+		}
+		// each content coding is a different representation
+		if encoding := header.Get(HeaderContentEncoding); encoding != "" {
+			value = strings.TrimSuffix(value, `"`) + "-" + encoding + `"`
+		}
+		header.Set(HeaderETag, value)
+	}
+	modTime := stat.ModTime()
+	if !lastModified {
+		modTime = time.Time{}
+	}
+	http.ServeContent(c.Writer, c.Request, stat.Name(), modTime, content)
+	return nil
+}
+
+// fileETag a strong ETag from the modification time and size, as nginx does,
+// or from a checksum of the content for files without modification time such
+// as embed.FS. If-Range only matches strong ETags.
+func fileETag(stat fs.FileInfo, content io.ReadSeeker) (string, error) {
+	version := stat.ModTime().UnixNano()
+	if stat.ModTime().IsZero() {
+		hash := crc32.NewIEEE()
+		if _, err := io.Copy(hash, content); err != nil {
+			return "", err
+		}
+		if _, err := content.Seek(0, io.SeekStart); err != nil {
+			return "", err
+		}
+		version = int64(hash.Sum32())
+	}
+	return `"` + strconv.FormatInt(version, 16) + "-" + strconv.FormatInt(stat.Size(), 16) + `"`, nil
+}
+
+// fileError maps an open error to 404 or 403
+func fileError(err error) error {
+	switch {
+	case os.IsNotExist(err):
+		return NewError(http.StatusNotFound)
+	case os.IsPermission(err):
+		return NewError(http.StatusForbidden)
+	}
+	return err
+}
+
+// contentDisposition formats the header by RFC 6266, with an ASCII filename
+// fallback and a UTF-8 filename* for other names:
+//
+//	attachment; filename="a_b.txt"; filename*=UTF-8''a%C3%A9b.txt
+func contentDisposition(dispositionType, filename string) string {
+	fallback, ascii := asciiFilename(filename)
+	disposition := dispositionType + `; filename="` + fallback + `"`
+	if !ascii {
+		disposition += "; filename*=UTF-8''" + encodeRFC5987(filename)
+	}
+	return disposition
+}
+
+// asciiFilename replaces characters which cannot be in a quoted string
+func asciiFilename(filename string) (string, bool) {
+	ascii := true
+	var b strings.Builder
+	for _, r := range filename {
+		switch {
+		case r == '"' || r == '\\' || r == '%':
+			ascii = false
+			b.WriteByte('_')
+		case r < 0x20 || r >= 0x7f:
+			ascii = false
+			b.WriteByte('_')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String(), ascii
+}
+
+const upperHex = "0123456789ABCDEF"
+
+// encodeRFC5987 percent-encodes all but attr-char
+func encodeRFC5987(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9' ||
+			strings.IndexByte("!#$&+-.^_`|~", ch) >= 0 {
+			b.WriteByte(ch)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(upperHex[ch>>4])
+		b.WriteByte(upperHex[ch&15])
+	}
+	return b.String()
+}
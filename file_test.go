@@ -0,0 +1,190 @@
+package seng
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestSendFile(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "report.txt")
+	if err := os.WriteFile(name, []byte("0123456789"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := os.Chtimes(name, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	e := New()
+	e.GET("/file", func(c *Context) error {
+		return c.SendFile(name)
+	})
+	e.GET("/missing", func(c *Context) error {
+		return c.SendFile(filepath.Join(dir, "missing.txt"))
+	})
+	e.GET("/dir", func(c *Context) error {
+		return c.SendFile(dir)
+	})
+	etag := serve(t, e, http.MethodGet, "/file", "").Header().Get(HeaderETag)
+	if !strings.HasPrefix(etag, `"`) {
+		t.Fatalf("ETag = %q, want a strong ETag", etag)
+	}
+
+	tests := []struct {
+		name     string
+		target   string
+		headers  []string
+		wantCode int
+		wantBody string
+	}{
+		{"full", "/file", nil, http.StatusOK, "0123456789"},
+		{"range", "/file", []string{"Range", "bytes=2-4"}, http.StatusPartialContent, "234"},
+		{"suffix range", "/file", []string{"Range", "bytes=-3"}, http.StatusPartialContent, "789"},
+		{"unsatisfiable range", "/file", []string{"Range", "bytes=20-"}, http.StatusRequestedRangeNotSatisfiable, ""},
+		{"if-range etag", "/file", []string{"Range", "bytes=0-1", "If-Range", etag}, http.StatusPartialContent, "01"},
+		{"if-range weak etag", "/file", []string{"Range", "bytes=0-1", "If-Range", "W/" + etag}, http.StatusOK, "0123456789"},
+		{"if-range other etag", "/file", []string{"Range", "bytes=0-1", "If-Range", `"other"`}, http.StatusOK, "0123456789"},
+		{"if-range date", "/file", []string{"Range", "bytes=0-1", "If-Range", modTime.Format(http.TimeFormat)}, http.StatusPartialContent, "01"},
+		{"if-range old date", "/file", []string{"Range", "bytes=0-1", "If-Range", modTime.Add(-time.Hour).Format(http.TimeFormat)}, http.StatusOK, "0123456789"},
+		{"if-none-match", "/file", []string{"If-None-Match", etag}, http.StatusNotModified, ""},
+		{"if-none-match weak", "/file", []string{"If-None-Match", "W/" + etag}, http.StatusNotModified, ""},
+		{"if-modified-since", "/file", []string{"If-Modified-Since", modTime.Format(http.TimeFormat)}, http.StatusNotModified, ""},
+		{"modified since", "/file", []string{"If-Modified-Since", modTime.Add(-time.Hour).Format(http.TimeFormat)}, http.StatusOK, "0123456789"},
+		{"missing", "/missing", nil, http.StatusNotFound, ""},
+		{"directory", "/dir", nil, http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(t, e, http.MethodGet, tt.target, "", tt.headers...)
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantCode)
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", w.Body, tt.wantBody)
+			}
+			if w.Code == http.StatusOK {
+				if got := w.Header().Get(HeaderContentType); !strings.HasPrefix(got, "text/plain") {
+					t.Errorf("Content-Type = %q", got)
+				}
+				if got := w.Header().Get("Last-Modified"); got != modTime.Format(http.TimeFormat) {
+					t.Errorf("Last-Modified = %q", got)
+				}
+			}
+		})
+	}
+}
+
+func TestSendFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"docs/guide.txt": {Data: []byte("guide")},
+		"docs/other.txt": {Data: []byte("other")},
+		"dated.txt":      {Data: []byte("guide"), ModTime: time.Unix(1, 0)},
+	}
+	e := New()
+	tests := []struct {
+		name     string
+		path     string
+		wantCode int
+	}{
+		{"file", "docs/guide.txt", http.StatusOK},
+		{"missing", "docs/missing.txt", http.StatusNotFound},
+		{"directory", "docs", http.StatusNotFound},
+		{"invalid path", "../docs/guide.txt", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestContext()
+			err := c.SendFS(fsys, tt.path)
+			code := http.StatusOK
+			if err != nil {
+				code = e.ResolveError(err).Code
+			}
+			if code != tt.wantCode {
+				t.Fatalf("status = %d, want %d (%v)", code, tt.wantCode, err)
+			}
+		})
+	}
+
+	// files without modification time get a checksum ETag
+	etags := map[string]string{}
+	for _, name := range []string{"docs/guide.txt", "docs/other.txt", "dated.txt"} {
+		c := newTestContext()
+		if err := c.SendFS(fsys, name); err != nil {
+			t.Fatal(err)
+		}
+		etags[name] = c.Writer.Header().Get(HeaderETag)
+		if !strings.HasPrefix(etags[name], `"`) {
+			t.Errorf("%s: ETag = %q, want a strong ETag", name, etags[name])
+		}
+	}
+	if etags["docs/guide.txt"] == etags["docs/other.txt"] || etags["docs/guide.txt"] == etags["dated.txt"] {
+		t.Errorf("ETags not distinct: %v", etags)
+	}
+}
+
+func TestDownload(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "report.pdf")
+	if err := os.WriteFile(name, []byte("%PDF"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name            string
+		send            func(c *Context) error
+		wantDisposition string
+		wantType        string
+	}{
+		{"download", func(c *Context) error { return c.Download(name) },
+			`attachment; filename="report.pdf"`, "application/pdf"},
+		{"download renamed", func(c *Context) error { return c.Download(name, "résumé.pdf") },
+			`attachment; filename="r_sum_.pdf"; filename*=UTF-8''r%C3%A9sum%C3%A9.pdf`, "application/pdf"},
+		{"inline", func(c *Context) error { return c.Inline(name) },
+			`inline; filename="report.pdf"`, "application/pdf"},
+		{"inline renamed", func(c *Context) error { return c.Inline(name, `a"b.pdf`) },
+			`inline; filename="a_b.pdf"; filename*=UTF-8''a%22b.pdf`, "application/pdf"},
+		{"attachment without name", func(c *Context) error { c.Attachment(); return c.Text("x") },
+			"attachment", MIMETextPlainCharsetUTF8},
+		{"attachment", func(c *Context) error { c.Attachment("data.json"); return c.Data([]byte("{}")) },
+			`attachment; filename="data.json"`, "application/json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New()
+			e.GET("/", tt.send)
+			w := serve(t, e, http.MethodGet, "/", "")
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d", w.Code)
+			}
+			if got := w.Header().Get(HeaderContentDisposition); got != tt.wantDisposition {
+				t.Errorf("Content-Disposition = %q, want %q", got, tt.wantDisposition)
+			}
+			if got := w.Header().Get(HeaderContentType); got != tt.wantType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantType)
+			}
+		})
+	}
+}
+
+func TestContentDisposition(t *testing.T) {
+	tests := []struct {
+		filename string
+		want     string
+	}{
+		{"a.txt", `attachment; filename="a.txt"`},
+		{"a b.txt", `attachment; filename="a b.txt"`},
+		{`a\b.txt`, `attachment; filename="a_b.txt"; filename*=UTF-8''a%5Cb.txt`},
+		{"100%.txt", `attachment; filename="100_.txt"; filename*=UTF-8''100%25.txt`},
+		{"a\nb.txt", `attachment; filename="a_b.txt"; filename*=UTF-8''a%0Ab.txt`},
+		{"日本.txt", `attachment; filename="__.txt"; filename*=UTF-8''%E6%97%A5%E6%9C%AC.txt`},
+	}
+	for _, tt := range tests {
+		if got := contentDisposition("attachment", tt.filename); got != tt.want {
+			t.Errorf("contentDisposition(%q) = %q, want %q", tt.filename, got, tt.want)
+		}
+	}
+}
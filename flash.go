@@ -0,0 +1,140 @@
+package seng
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// DefaultFlashCookieName name of the cookie of CookieFlashStore
+const DefaultFlashCookieName = "seng_flash"
+
+// Flash a one-shot message, see Context.Flash
+type Flash struct {
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+// FlashStore persists flashes between requests, see Config.FlashStore
+type FlashStore interface {
+	// LoadFlashes returns the flashes saved by the previous requests
+	LoadFlashes(c *Context) ([]Flash, error)
+	// SaveFlashes saves flashes for the next requests, an empty slice removes them
+	SaveFlashes(c *Context, flashes []Flash) error
+}
+
+// CookieFlashStore keeps flashes in a cookie signed with Config.CookieKeys
+type CookieFlashStore struct {
+	// Name of the cookie
+	// Default: "seng_flash"
+	Name string
+}
+
+var _ FlashStore = CookieFlashStore{}
+
+// name returns the cookie name
+func (s CookieFlashStore) name() string {
+	if s.Name == "" {
+		return DefaultFlashCookieName
+	}
+	return s.Name
+}
+
+// LoadFlashes implements FlashStore, modified cookies are ignored
+func (s CookieFlashStore) LoadFlashes(c *Context) ([]Flash, error) {
+	value, err := c.GetSignedCookie(s.name())
+	if err == http.ErrNoCookie || err == ErrInvalidCookie || err == ErrExpiredCookie {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var flashes []Flash
+	if err := json.Unmarshal([]byte(value), &flashes); err != nil {
+		return nil, nil
+	}
+	return flashes, nil
+}
+
+// SaveFlashes implements FlashStore
+func (s CookieFlashStore) SaveFlashes(c *Context, flashes []Flash) error {
+	if len(flashes) == 0 {
+		if _, err := c.GetCookie(s.name()); err == nil {
+			c.ClearCookie(s.name())
+		}
+		return nil
+	}
+	value, err := json.Marshal(flashes)
+	if err != nil {
+		return err
+	}
+	return c.Cookie(s.name()).Value(string(value)).HttpOnly(true).Signed().Set()
+}
+
+// flashState the flashes of a request
+type flashState struct {
+	loaded bool
+	// flashes of the previous requests
+	previous []Flash
+	consumed bool
+	// flashes for the next requests
+	next []Flash
+}
+
+// flashState returns the flashes of the request, the flashes are saved
+// before the response is sent
+func (c *Context) flashState() *flashState {
+	if c.flash == nil {
+		c.flash = new(flashState)
+		c.BeforeWrite(c.saveFlashes)
+	}
+	return c.flash
+}
+
+// Flash adds a message for the next request, e.g. after a redirect
+//
+//	c.Flash("success", "Profile saved")
+//	return c.Redirect(http.StatusSeeOther, "/profile")
+func (c *Context) Flash(kind, message string) {
+	state := c.flashState()
+	state.next = append(state.next, Flash{Kind: kind, Message: message})
+}
+
+// Flashes returns and consumes the messages of the previous requests. Templates
+// rendered by Context.HTML read them with the flashes function:
+//
+//	{{range flashes}}<div class="{{.Kind}}">{{.Message}}</div>{{end}}
+//
+// Contexts without engine, see NewContext, have no flash store and no flashes.
+func (c *Context) Flashes() []Flash {
+	state := c.flashState()
+	if !state.loaded && c.engine != nil {
+		state.loaded = true
+		flashes, err := c.engine.config.FlashStore.LoadFlashes(c)
+		if err != nil {
+			c.engine.Logger.Printf("seng: flashes: %v", err)
+		}
+		state.previous = flashes
+	}
+	state.consumed = true
+	return state.previous
+}
+
+// saveFlashes saves the new flashes and removes the consumed ones
+func (c *Context) saveFlashes() {
+	state := c.flash
+	if c.engine == nil || len(state.next) == 0 && !state.consumed {
+		return
+	}
+	flashes := state.next
+	if !state.consumed {
+		// keep the unread flashes of the previous requests
+		previous, err := c.engine.config.FlashStore.LoadFlashes(c)
+		if err != nil {
+			c.engine.Logger.Printf("seng: flashes: %v", err)
+		}
+		flashes = append(previous, flashes...)
+	}
+	if err := c.engine.config.FlashStore.SaveFlashes(c, flashes); err != nil {
+		c.engine.Logger.Printf("seng: flashes: %v", err)
+	}
+}
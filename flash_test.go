@@ -0,0 +1,155 @@
+package seng
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// testClient serves requests with the cookies set by the previous responses
+type testClient struct {
+	h       http.Handler
+	cookies map[string]string
+}
+
+// get serves target and keeps the cookies of the response
+func (client *testClient) get(target string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	for name, value := range client.cookies {
+		req.AddCookie(&http.Cookie{Name: name, Value: value})
+	}
+	w := httptest.NewRecorder()
+	client.h.ServeHTTP(w, req)
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.MaxAge < 0 {
+			delete(client.cookies, cookie.Name)
+			continue
+		}
+		client.cookies[cookie.Name] = cookie.Value
+	}
+	return w
+}
+
+// newFlashEngine returns an engine with handlers adding and reading flashes
+func newFlashEngine(config Config) *Engine {
+	e := New(config)
+	e.GET("/add", func(c *Context) error {
+		c.Flash(c.Query("kind", "info"), c.Query("message"))
+		return nil
+	})
+	e.GET("/read", func(c *Context) error {
+		var messages []string
+		for _, flash := range c.Flashes() {
+			messages = append(messages, flash.Kind+":"+flash.Message)
+		}
+		if c.Query("message") != "" {
+			c.Flash("info", c.Query("message"))
+		}
+		return c.Text(strings.Join(messages, ","))
+	})
+	e.GET("/other", func(c *Context) error {
+		return c.Text("other")
+	})
+	return e
+}
+
+func TestFlash(t *testing.T) {
+	type step struct {
+		target string
+		want   string
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{"next request", []step{{"/add?message=saved", ""}, {"/read", "info:saved"}, {"/read", ""}}},
+		{"several", []step{
+			{"/add?message=a", ""},
+			{"/add?kind=error&message=b", ""},
+			{"/read", "info:a,error:b"},
+		}},
+		{"kept until read", []step{{"/add?message=a", ""}, {"/other", "other"}, {"/other", "other"}, {"/read", "info:a"}}},
+		{"read and add", []step{{"/add?message=a", ""}, {"/read?message=b", "info:a"}, {"/read", "info:b"}, {"/read", ""}}},
+		{"nothing", []step{{"/read", ""}, {"/other", "other"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &testClient{h: newFlashEngine(Config{CookieKeys: [][]byte{testCookieKey}}), cookies: map[string]string{}}
+			for i, step := range tt.steps {
+				if got := client.get(step.target).Body.String(); got != step.want {
+					t.Errorf("step %d %s = %q, want %q", i, step.target, got, step.want)
+				}
+			}
+			if _, ok := client.cookies[DefaultFlashCookieName]; ok {
+				t.Errorf("flash cookie left after the flashes were read: %v", client.cookies)
+			}
+		})
+	}
+}
+
+func TestCookieFlashStore(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		// modify changes the flash cookie before it is read
+		modify func(value string) string
+		want   string
+	}{
+		{"signed", Config{CookieKeys: [][]byte{testCookieKey}}, nil, "info:saved"},
+		{"cookie name", Config{CookieKeys: [][]byte{testCookieKey}, FlashStore: CookieFlashStore{Name: "notice"}}, nil, "info:saved"},
+		{"modified", Config{CookieKeys: [][]byte{testCookieKey}}, func(value string) string { return "x" + value }, ""},
+		{"not signed", Config{CookieKeys: [][]byte{testCookieKey}}, func(string) string { return `[{"kind":"info","message":"forged"}]` }, ""},
+		{"no cookie keys", Config{}, nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &testClient{h: newFlashEngine(tt.config), cookies: map[string]string{}}
+			client.get("/add?message=saved")
+			name := DefaultFlashCookieName
+			if store, ok := tt.config.FlashStore.(CookieFlashStore); ok {
+				name = store.Name
+			}
+			if tt.modify != nil {
+				client.cookies[name] = tt.modify(client.cookies[name])
+			}
+			if got := client.get("/read").Body.String(); got != tt.want {
+				t.Errorf("flashes = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlashesTemplate(t *testing.T) {
+	e := newFlashEngine(Config{CookieKeys: [][]byte{testCookieKey}})
+	if err := e.LoadHTMLFS(fstest.MapFS{
+		"flashes.html": {Data: []byte(`{{range flashes}}<p class="{{.Kind}}">{{.Message}}</p>{{end}}`)},
+	}, "*.html"); err != nil {
+		t.Fatal(err)
+	}
+	e.GET("/page", func(c *Context) error { return c.HTML("flashes.html", nil) })
+	client := &testClient{h: e, cookies: map[string]string{}}
+	client.get("/add?kind=success&message=<saved>")
+	tests := []string{`<p class="success">&lt;saved&gt;</p>`, ""}
+	for i, want := range tests {
+		if got := client.get("/page").Body.String(); got != want {
+			t.Errorf("render %d = %q, want %q", i, got, want)
+		}
+	}
+}
+
+func TestFlashesWithoutEngine(t *testing.T) {
+	w := httptest.NewRecorder()
+	c := NewContext(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	c.Flash("info", "saved")
+	if flashes := c.Flashes(); len(flashes) != 0 {
+		t.Errorf("Flashes = %v", flashes)
+	}
+	if err := c.Text("ok"); err != nil {
+		t.Fatal(err)
+	}
+	if got := w.Header().Get(HeaderSetCookie); got != "" {
+		t.Errorf("Set-Cookie = %q", got)
+	}
+}
@@ -14,6 +14,11 @@ type RouterGroup struct {
 	prefix string
 	// middlewares
 	middleWares []Handler
+	// see OnError
+	errorHandlers []ErrorHandler
+	// see SetErrorHandler and SetNotFoundHandler
+	errorHandler    ErrorHandler
+	notFoundHandler Handler
 	// tree
 	parent *RouterGroup
 	// reference to engine
@@ -33,55 +38,70 @@ func (g *RouterGroup) Group(prefix string) *RouterGroup {
 }
 
 // addRoute add route to router
-func (g *RouterGroup) addRoute(method string, pattern string, handler Handler) {
+func (g *RouterGroup) addRoute(method string, pattern string, handler Handler) *Route {
 	// get only config
 	if g.engine.config.GETOnly && method != http.MethodGet {
-		return
+		return nil
 	}
 	pattern = g.prefix + pattern
 	// print routes
 	if g.engine.config.Debug {
 		g.engine.Logger.Printf("Route %4s - %s", method, pattern)
 	}
-	g.engine.router.addRoute(method, pattern, handler)
+	return g.engine.router.addRoute(method, pattern, handler)
+}
+
+func (g *RouterGroup) GET(pattern string, handler Handler) *Route {
+	return g.addRoute(http.MethodGet, pattern, handler)
 }
 
-func (g *RouterGroup) GET(pattern string, handler Handler) {
-	g.addRoute(http.MethodGet, pattern, handler)
+func (g *RouterGroup) POST(pattern string, handler Handler) *Route {
+	return g.addRoute(http.MethodPost, pattern, handler)
 }
 
-func (g *RouterGroup) POST(pattern string, handler Handler) {
-	g.addRoute(http.MethodPost, pattern, handler)
+func (g *RouterGroup) HEAD(pattern string, handler Handler) *Route {
+	return g.addRoute(http.MethodHead, pattern, handler)
 }
 
-func (g *RouterGroup) HEAD(pattern string, handler Handler) {
-	g.addRoute(http.MethodHead, pattern, handler)
+func (g *RouterGroup) PUT(pattern string, handler Handler) *Route {
+	return g.addRoute(http.MethodPut, pattern, handler)
 }
 
-func (g *RouterGroup) PUT(pattern string, handler Handler) {
-	g.addRoute(http.MethodPut, pattern, handler)
+func (g *RouterGroup) DELETE(pattern string, handler Handler) *Route {
+	return g.addRoute(http.MethodDelete, pattern, handler)
 }
 
-func (g *RouterGroup) DELETE(pattern string, handler Handler) {
-	g.addRoute(http.MethodDelete, pattern, handler)
+func (g *RouterGroup) TRACE(pattern string, handler Handler) *Route {
+	return g.addRoute(http.MethodTrace, pattern, handler)
 }
 
-func (g *RouterGroup) TRACE(pattern string, handler Handler) {
-	g.addRoute(http.MethodTrace, pattern, handler)
+func (g *RouterGroup) CONNECT(pattern string, handler Handler) *Route {
+	return g.addRoute(http.MethodConnect, pattern, handler)
 }
 
-func (g *RouterGroup) CONNECT(pattern string, handler Handler) {
-	g.addRoute(http.MethodConnect, pattern, handler)
+func (g *RouterGroup) OPTIONS(pattern string, handler Handler) *Route {
+	return g.addRoute(http.MethodOptions, pattern, handler)
 }
 
-func (g *RouterGroup) OPTIONS(pattern string, handler Handler) {
-	g.addRoute(http.MethodOptions, pattern, handler)
+// Typed registers a typed handler, see Typed.
+// The request and response types are recorded on the route for docs generation.
+// g.Typed(http.MethodPost, "/pets", func(c *seng.Context, in *NewPet) (*Pet, error) {...})
+func (g *RouterGroup) Typed(method string, pattern string, fn interface{}) *Route {
+	typed := newTypedHandler(fn)
+	route := g.addRoute(method, pattern, typed.handle)
+	if route != nil {
+		route.Request = typed.in
+		route.Response = typed.out
+	}
+	return route
 }
 
 func (g *RouterGroup) Use(middleWares ...Handler) {
 	g.middleWares = append(g.middleWares, middleWares...)
 }
 
+// CreateStaticHandler creates a handler of the filepath param serving the
+// files of fs with http.FileServer, see Static for more options
 func (g *RouterGroup) CreateStaticHandler(relativePath string, fs http.FileSystem) Handler {
 	absolutePath := path.Join(g.prefix, relativePath)
 	fileServer := http.StripPrefix(absolutePath, http.FileServer(fs))
@@ -91,18 +111,13 @@ func (g *RouterGroup) CreateStaticHandler(relativePath string, fs http.FileSyste
 			return errors.New("filepath is not exists")
 		}
 		// Determine whether the file exists or have permission to process the file
-		if _, err := fs.Open(file); err != nil {
+		f, err := fs.Open(file)
+		if err != nil {
 			ctx.Status(http.StatusNotFound)
 			return nil
 		}
+		f.Close()
 		fileServer.ServeHTTP(ctx.Writer, ctx.Request)
 		return nil
 	}
 }
-
-// Static Map the root path on the hard disk to the routing relativePath
-func (g *RouterGroup) Static(relativePath string, root string) {
-	handler := g.CreateStaticHandler(relativePath, http.Dir(root))
-	urlPattern := path.Join(relativePath, "/*filepath")
-	g.GET(urlPattern, handler)
-}
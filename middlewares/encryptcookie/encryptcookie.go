@@ -0,0 +1,84 @@
+package encryptcookie
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/seefs001/seng"
+)
+
+// Config represents all available options for the middleware.
+type Config struct {
+	// Except names of the cookies which are not encrypted, e.g. cookies read by JavaScript
+	Except []string
+}
+
+// DefaultConfig returns a generic default configuration
+func DefaultConfig() Config {
+	return Config{}
+}
+
+// Default returns the middleware with default configuration.
+func Default() seng.Handler {
+	return New(DefaultConfig())
+}
+
+// New returns the middleware which encrypts all cookies but Config.Except
+// with seng.Config.CookieKeys. Cookies of the request are decrypted before
+// the next handlers read them with the Context, cookies which fail to decrypt
+// or expired are removed. The Cookie header of the request is not changed.
+// Cookies set with the Context methods are encrypted until they expire,
+// cookies written to the Set-Cookie header directly are not.
+//
+//	e := seng.New(seng.Config{CookieKeys: [][]byte{key}})
+//	e.Use(encryptcookie.New(encryptcookie.Config{Except: []string{"theme"}}))
+func New(config Config) seng.Handler {
+	except := make(map[string]bool, len(config.Except))
+	for _, name := range config.Except {
+		except[name] = true
+	}
+	return func(c *seng.Context) error {
+		engine := c.Engine()
+		c.SetRequestCookies(decryptCookies(engine, c.GetCookies(), except))
+		c.OnSetCookie(func(cookie *http.Cookie) error {
+			return encryptCookie(engine, cookie, except)
+		})
+		return c.Next()
+	}
+}
+
+// decryptCookies returns cookies with their values decrypted, without the
+// cookies which fail to decrypt
+func decryptCookies(engine *seng.Engine, cookies []*http.Cookie, except map[string]bool) []*http.Cookie {
+	decrypted := make([]*http.Cookie, 0, len(cookies))
+	for _, cookie := range cookies {
+		if !except[cookie.Name] {
+			value, err := engine.DecryptCookie(cookie.Name, cookie.Value)
+			if err != nil {
+				continue
+			}
+			cookie = &http.Cookie{Name: cookie.Name, Value: value}
+		}
+		decrypted = append(decrypted, cookie)
+	}
+	return decrypted
+}
+
+// encryptCookie encrypts the value of cookie until it expires
+func encryptCookie(engine *seng.Engine, cookie *http.Cookie, except map[string]bool) error {
+	// deleted cookies have no value
+	if except[cookie.Name] || cookie.Value == "" {
+		return nil
+	}
+	expires := cookie.Expires
+	if cookie.MaxAge > 0 {
+		expires = time.Now().Add(time.Duration(cookie.MaxAge) * time.Second)
+	}
+	value, err := engine.EncryptCookie(cookie.Name, cookie.Value, expires)
+	if err != nil {
+		return fmt.Errorf("encryptcookie: %w", err)
+	}
+	cookie.Value = value
+	return nil
+}
@@ -0,0 +1,133 @@
+package encryptcookie
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/seefs001/seng"
+)
+
+var testKey = []byte("0123456789abcdef0123456789abcdef")
+
+// newTestEngine returns an engine encrypting the cookies but theme
+func newTestEngine() *seng.Engine {
+	e := seng.New(seng.Config{CookieKeys: [][]byte{testKey}})
+	e.Use(New(Config{Except: []string{"theme"}}))
+	e.GET("/set", func(c *seng.Context) error {
+		c.SetCookie(&http.Cookie{Name: "uid", Value: "42", Path: "/app", Secure: true, SameSite: http.SameSiteNoneMode, Partitioned: true})
+		c.SetCookie(&http.Cookie{Name: "theme", Value: "dark"})
+		c.SetCookie(&http.Cookie{Name: "old", Value: "1", Expires: time.Now().Add(-time.Hour)})
+		// cookies set before the response is sent are encrypted too
+		c.BeforeWrite(func() {
+			c.SetCookie(&http.Cookie{Name: "late", Value: "7", MaxAge: 60})
+		})
+		return c.Text("ok")
+	})
+	e.GET("/get", func(c *seng.Context) error {
+		names := []string{}
+		for _, cookie := range c.GetCookies() {
+			names = append(names, cookie.Name+"="+cookie.Value)
+		}
+		return c.Text(strings.Join(names, " "))
+	})
+	return e
+}
+
+func serve(e *seng.Engine, target string, cookies ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if len(cookies) > 0 {
+		req.Header.Set(seng.HeaderCookie, strings.Join(cookies, "; "))
+	}
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	return w
+}
+
+func TestEncryptCookies(t *testing.T) {
+	e := newTestEngine()
+	headers := serve(e, "/set").Header()[seng.HeaderSetCookie]
+	set := make(map[string]string, len(headers))
+	for _, header := range headers {
+		set[strings.SplitN(header, "=", 2)[0]] = header
+	}
+	tests := []struct {
+		name      string
+		encrypted bool
+		// attributes the Set-Cookie header keeps
+		attributes []string
+	}{
+		{"uid", true, []string{"Path=/app", "Secure", "SameSite=None", "Partitioned"}},
+		{"theme", false, nil},
+		{"old", true, []string{"Expires="}},
+		{"late", true, []string{"Max-Age=60"}},
+	}
+	for _, tt := range tests {
+		header, ok := set[tt.name]
+		if !ok {
+			t.Errorf("%s: no Set-Cookie in %q", tt.name, headers)
+			continue
+		}
+		value := strings.SplitN(strings.SplitN(header, ";", 2)[0], "=", 2)[1]
+		if encrypted := value != "42" && value != "dark" && value != "1" && value != "7"; encrypted != tt.encrypted {
+			t.Errorf("%s: Set-Cookie %q, encrypted %v", tt.name, header, tt.encrypted)
+		}
+		for _, attribute := range tt.attributes {
+			if !strings.Contains(header, attribute) {
+				t.Errorf("%s: Set-Cookie %q without %s", tt.name, header, attribute)
+			}
+		}
+	}
+
+	var cookies []string
+	for _, header := range headers {
+		cookies = append(cookies, strings.SplitN(header, ";", 2)[0])
+	}
+	// the expired cookie and modified cookies are removed
+	cookies = append(cookies, "forged=42")
+	if got := serve(e, "/get", cookies...).Body.String(); got != "uid=42 theme=dark late=7" {
+		t.Errorf("decrypted cookies = %q", got)
+	}
+}
+
+func TestEncryptCookiesClear(t *testing.T) {
+	e := newTestEngine()
+	e.GET("/clear", func(c *seng.Context) error {
+		c.ClearCookie("uid")
+		return nil
+	})
+	header := serve(e, "/clear").Header().Get(seng.HeaderSetCookie)
+	// deleted cookies have no value to encrypt
+	if !strings.HasPrefix(header, "uid=;") || !strings.Contains(header, "Max-Age=0") {
+		t.Errorf("Set-Cookie = %q", header)
+	}
+}
+
+func TestEncryptCookiesRoundTrip(t *testing.T) {
+	values := []string{`{"a":"b;c"}`, `say "hi"`, `a\b`, "héllo wörld", "a=b; c=d"}
+	for _, value := range values {
+		e := seng.New(seng.Config{CookieKeys: [][]byte{testKey}})
+		e.Use(Default())
+		e.GET("/set", func(c *seng.Context) error {
+			c.SetCookie(&http.Cookie{Name: "data", Value: value})
+			return nil
+		})
+		var got string
+		var ok bool
+		e.GET("/get", func(c *seng.Context) error {
+			cookie, err := c.GetCookie("data")
+			ok = err == nil && len(c.GetCookies()) == 1
+			if ok {
+				got = cookie.Value
+			}
+			return nil
+		})
+		header := serve(e, "/set").Header().Get(seng.HeaderSetCookie)
+		serve(e, "/get", strings.SplitN(header, ";", 2)[0])
+		if !ok || got != value {
+			t.Errorf("cookie %q read as %q", value, got)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package session
+
+import (
+	"time"
+)
+
+// Config represents all available options for the middleware.
+type Config struct {
+	// Store persists the sessions
+	// Default: NewMemoryStore()
+	Store Store
+
+	// CookieName name of the session cookie, its other attributes are those
+	// of seng.Config.Cookie but HttpOnly
+	// Default: "session_id"
+	CookieName string
+
+	// IdleTimeout a session expires when it was not used for IdleTimeout
+	// Default: 30 minutes
+	IdleTimeout time.Duration
+
+	// AbsoluteTimeout a session expires AbsoluteTimeout after its creation,
+	// even if it is used
+	// Default: 24 hours
+	AbsoluteTimeout time.Duration
+}
+
+// Default Config values
+const (
+	DefaultCookieName      = "session_id"
+	DefaultIdleTimeout     = 30 * time.Minute
+	DefaultAbsoluteTimeout = 24 * time.Hour
+)
+
+// DefaultConfig returns a generic default configuration
+func DefaultConfig() Config {
+	return Config{
+		CookieName:      DefaultCookieName,
+		IdleTimeout:     DefaultIdleTimeout,
+		AbsoluteTimeout: DefaultAbsoluteTimeout,
+	}
+}
+
+// withDefaults fills the zero values of config
+func (config Config) withDefaults() Config {
+	if config.Store == nil {
+		config.Store = NewMemoryStore()
+	}
+	if config.CookieName == "" {
+		config.CookieName = DefaultCookieName
+	}
+	if config.IdleTimeout == 0 {
+		config.IdleTimeout = DefaultIdleTimeout
+	}
+	if config.AbsoluteTimeout == 0 {
+		config.AbsoluteTimeout = DefaultAbsoluteTimeout
+	}
+	return config
+}
@@ -0,0 +1,58 @@
+package session
+
+import (
+	"errors"
+	"time"
+
+	"github.com/seefs001/seng"
+)
+
+// MaxCookieSize maximum size of the cookie of CookieStore
+const MaxCookieSize = 4096
+
+// ErrCookieTooLarge is returned by CookieStore for sessions larger than MaxCookieSize
+var ErrCookieTooLarge = errors.New("session: the session is too large for a cookie")
+
+// cookieStoreName name the session data are signed for
+const cookieStoreName = "session"
+
+// CookieStore keeps sessions in the session cookie, signed with
+// seng.Config.CookieKeys. The values can be read but not modified by
+// clients, and deleted sessions cannot be revoked before they expire.
+type CookieStore struct{}
+
+var _ Store = CookieStore{}
+
+// NewCookieStore creates a CookieStore
+func NewCookieStore() CookieStore {
+	return CookieStore{}
+}
+
+// Load implements Store
+func (CookieStore) Load(c *seng.Context, token string) ([]byte, error) {
+	value, err := c.Engine().VerifyCookie(cookieStoreName, token)
+	if errors.Is(err, seng.ErrInvalidCookie) || errors.Is(err, seng.ErrExpiredCookie) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []byte(value), nil
+}
+
+// Save implements Store, it returns the signed data
+func (CookieStore) Save(c *seng.Context, _ string, data []byte, expiry time.Time) (string, error) {
+	token, err := c.Engine().SignCookie(cookieStoreName, string(data), expiry)
+	if err != nil {
+		return "", err
+	}
+	if len(token) > MaxCookieSize {
+		return "", ErrCookieTooLarge
+	}
+	return token, nil
+}
+
+// Delete implements Store, the cookie is cleared by Session.Destroy
+func (CookieStore) Delete(_ *seng.Context, _ string) error {
+	return nil
+}
@@ -0,0 +1,118 @@
+package session
+
+import (
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/seefs001/seng"
+)
+
+// FileStore keeps sessions in files of a directory, one file per session
+type FileStore struct {
+	dir string
+}
+
+var _ Store = (*FileStore)(nil)
+
+// NewFileStore creates a FileStore in dir, which is created if needed
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// path returns the file of the session id, false if id is not a session ID
+func (s *FileStore) path(id string) (string, bool) {
+	if id == "" || strings.Trim(id, "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_") != "" {
+		return "", false
+	}
+	return filepath.Join(s.dir, "session_"+id), true
+}
+
+// Load implements Store
+func (s *FileStore) Load(_ *seng.Context, token string) ([]byte, error) {
+	path, ok := s.path(token)
+	if !ok {
+		return nil, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	// the expiry precedes the data
+	if len(data) < 8 || time.Now().UnixNano() > int64(binary.BigEndian.Uint64(data)) {
+		return nil, nil
+	}
+	return data[8:], nil
+}
+
+// Save implements Store, the file is replaced atomically
+func (s *FileStore) Save(_ *seng.Context, id string, data []byte, expiry time.Time) (string, error) {
+	path, ok := s.path(id)
+	if !ok {
+		return "", os.ErrInvalid
+	}
+	file, err := ioutil.TempFile(s.dir, "tmp_")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(file.Name())
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint64(header, uint64(expiry.UnixNano()))
+	if _, err = file.Write(append(header, data...)); err != nil {
+		file.Close()
+		return "", err
+	}
+	if err = file.Close(); err != nil {
+		return "", err
+	}
+	if err = os.Rename(file.Name(), path); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// Delete implements Store
+func (s *FileStore) Delete(_ *seng.Context, token string) error {
+	path, ok := s.path(token)
+	if !ok {
+		return nil
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// GC removes the files of expired sessions, call it periodically
+func (s *FileStore) GC() error {
+	paths, err := filepath.Glob(filepath.Join(s.dir, "session_*"))
+	if err != nil {
+		return err
+	}
+	now := time.Now().UnixNano()
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		header := make([]byte, 8)
+		_, err = io.ReadFull(file, header)
+		file.Close()
+		if err != nil || now > int64(binary.BigEndian.Uint64(header)) {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
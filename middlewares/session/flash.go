@@ -0,0 +1,38 @@
+package session
+
+import (
+	"encoding/gob"
+
+	"github.com/seefs001/seng"
+)
+
+func init() {
+	gob.Register([]seng.Flash(nil))
+}
+
+// flashesKey key of the flashes in the session
+const flashesKey = "seng/flashes"
+
+// FlashStore keeps the flashes of seng.Context.Flash in the session, the
+// middleware must be used before the handlers adding flashes
+//
+//	e := seng.New(seng.Config{FlashStore: session.FlashStore{}})
+type FlashStore struct{}
+
+var _ seng.FlashStore = FlashStore{}
+
+// LoadFlashes implements seng.FlashStore
+func (FlashStore) LoadFlashes(c *seng.Context) ([]seng.Flash, error) {
+	flashes, _ := Get(c).Get(flashesKey).([]seng.Flash)
+	return flashes, nil
+}
+
+// SaveFlashes implements seng.FlashStore
+func (FlashStore) SaveFlashes(c *seng.Context, flashes []seng.Flash) error {
+	if len(flashes) == 0 {
+		Get(c).Delete(flashesKey)
+		return nil
+	}
+	Get(c).Set(flashesKey, flashes)
+	return nil
+}
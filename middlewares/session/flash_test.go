@@ -0,0 +1,50 @@
+package session
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/seefs001/seng"
+)
+
+func TestFlashStore(t *testing.T) {
+	e := seng.New(seng.Config{FlashStore: FlashStore{}})
+	e.Use(New(Config{}))
+	e.GET("/add", func(c *seng.Context) error {
+		c.Flash("info", c.Query("message"))
+		return nil
+	})
+	e.GET("/read", func(c *seng.Context) error {
+		var messages []string
+		for _, flash := range c.Flashes() {
+			messages = append(messages, flash.Kind+":"+flash.Message)
+		}
+		return c.Text(strings.Join(messages, ","))
+	})
+	e.GET("/other", func(c *seng.Context) error {
+		return nil
+	})
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{"/add?message=a", ""},
+		{"/add?message=b", ""},
+		{"/other", ""},
+		{"/read", "info:a,info:b"},
+		{"/read", ""},
+	}
+	var token string
+	for i, tt := range tests {
+		got, header := request(e, tt.target, token)
+		if header != "" {
+			token = cookieValue(header)
+		}
+		if got != tt.want {
+			t.Errorf("step %d %s = %q, want %q", i, tt.target, got, tt.want)
+		}
+	}
+	if token == "" {
+		t.Error("the flashes were not saved in the session")
+	}
+}
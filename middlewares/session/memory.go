@@ -0,0 +1,105 @@
+package session
+
+import (
+	"sync"
+	"time"
+
+	"github.com/seefs001/seng"
+)
+
+// DefaultGCInterval interval of the removal of expired sessions of MemoryStore
+const DefaultGCInterval = 10 * time.Minute
+
+// memoryEntry a session of MemoryStore
+type memoryEntry struct {
+	data   []byte
+	expiry time.Time
+}
+
+// MemoryStore keeps sessions in memory, they are lost on restart and not
+// shared between instances
+type MemoryStore struct {
+	mutex    sync.RWMutex
+	sessions map[string]memoryEntry
+	done     chan struct{}
+	once     sync.Once
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+// NewMemoryStore creates a MemoryStore which removes expired sessions every
+// gcInterval, DefaultGCInterval by default
+func NewMemoryStore(gcInterval ...time.Duration) *MemoryStore {
+	interval := DefaultGCInterval
+	if len(gcInterval) > 0 {
+		interval = gcInterval[0]
+	}
+	s := &MemoryStore{
+		sessions: make(map[string]memoryEntry),
+		done:     make(chan struct{}),
+	}
+	go s.gc(interval)
+	return s
+}
+
+// gc removes expired sessions every interval until Close
+func (s *MemoryStore) gc(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case now := <-ticker.C:
+			s.mutex.Lock()
+			for id, entry := range s.sessions {
+				if now.After(entry.expiry) {
+					delete(s.sessions, id)
+				}
+			}
+			s.mutex.Unlock()
+		}
+	}
+}
+
+// Load implements Store
+func (s *MemoryStore) Load(_ *seng.Context, token string) ([]byte, error) {
+	s.mutex.RLock()
+	entry, ok := s.sessions[token]
+	s.mutex.RUnlock()
+	if !ok || time.Now().After(entry.expiry) {
+		return nil, nil
+	}
+	return entry.data, nil
+}
+
+// Save implements Store
+func (s *MemoryStore) Save(_ *seng.Context, id string, data []byte, expiry time.Time) (string, error) {
+	s.mutex.Lock()
+	s.sessions[id] = memoryEntry{data: append([]byte(nil), data...), expiry: expiry}
+	s.mutex.Unlock()
+	return id, nil
+}
+
+// Delete implements Store
+func (s *MemoryStore) Delete(_ *seng.Context, token string) error {
+	s.mutex.Lock()
+	delete(s.sessions, token)
+	s.mutex.Unlock()
+	return nil
+}
+
+// Len returns the number of sessions, including expired ones not yet removed
+func (s *MemoryStore) Len() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return len(s.sessions)
+}
+
+// Close stops the removal of expired sessions
+func (s *MemoryStore) Close() error {
+	s.once.Do(func() {
+		close(s.done)
+	})
+	return nil
+}
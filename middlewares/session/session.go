@@ -0,0 +1,252 @@
+package session
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/gob"
+	"time"
+
+	"github.com/seefs001/seng"
+)
+
+// Store persists sessions. The token is the value of the session cookie,
+// which is the session ID for server side stores.
+type Store interface {
+	// Load returns the data of the session of token, nil if it does not exist or expired
+	Load(c *seng.Context, token string) ([]byte, error)
+	// Save stores the data of the session id until expiry and returns the token of the cookie
+	Save(c *seng.Context, id string, data []byte, expiry time.Time) (string, error)
+	// Delete removes the session of token
+	Delete(c *seng.Context, token string) error
+}
+
+// contextKey key of the session in Context.Values
+const contextKey = "seng/session"
+
+// touchInterval sessions which are not modified are saved at most once per
+// touchInterval to extend the idle timeout
+const touchInterval = time.Minute
+
+// record the stored session, values of custom types must be registered with gob.Register
+type record struct {
+	ID       string
+	Created  time.Time
+	Accessed time.Time
+	Values   map[string]interface{}
+}
+
+// Session of a request, see Get
+type Session struct {
+	c      *seng.Context
+	config Config
+	record record
+	// token of the session cookie of the request
+	token string
+	// token of the session before Regenerate, deleted by Save
+	oldToken  string
+	isNew     bool
+	modified  bool
+	destroyed bool
+}
+
+// Default returns the middleware with default configuration.
+func Default() seng.Handler {
+	return New(DefaultConfig())
+}
+
+// New returns the middleware which loads the session of the request, see
+// Get. Modified sessions are saved before the response is sent.
+//
+//	e.Use(session.New(session.Config{Store: session.NewFileStore("./sessions")}))
+func New(config Config) seng.Handler {
+	config = config.withDefaults()
+	return func(c *seng.Context) error {
+		s := load(c, config)
+		c.Set(contextKey, s)
+		c.BeforeWrite(func() {
+			if err := s.Save(); err != nil {
+				c.Engine().Logger.Printf("session: %v", err)
+			}
+		})
+		return c.Next()
+	}
+}
+
+// Get returns the session of the request, it panics if the middleware is not used
+//
+//	s := session.Get(c)
+//	s.Set("user_id", user.ID)
+func Get(c *seng.Context) *Session {
+	value, ok := c.Get(contextKey)
+	if !ok {
+		panic("session: the session middleware is not used")
+	}
+	return value.(*Session)
+}
+
+// load loads the session of the session cookie, or creates a new one
+func load(c *seng.Context, config Config) *Session {
+	s := &Session{c: c, config: config}
+	if cookie, err := c.GetCookie(config.CookieName); err == nil && cookie.Value != "" {
+		s.token = cookie.Value
+		if s.loadRecord() {
+			return s
+		}
+	}
+	now := time.Now()
+	s.record = record{
+		ID:       newID(),
+		Created:  now,
+		Accessed: now,
+		Values:   make(map[string]interface{}),
+	}
+	s.isNew = true
+	return s
+}
+
+// loadRecord loads the session of the token, it reports whether the session
+// exists and did not time out
+func (s *Session) loadRecord() bool {
+	data, err := s.config.Store.Load(s.c, s.token)
+	if err != nil {
+		s.c.Engine().Logger.Printf("session: %v", err)
+		return false
+	}
+	if data == nil {
+		return false
+	}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&s.record); err != nil {
+		s.c.Engine().Logger.Printf("session: %v", err)
+		return false
+	}
+	now := time.Now()
+	if now.Sub(s.record.Accessed) > s.config.IdleTimeout || now.Sub(s.record.Created) > s.config.AbsoluteTimeout {
+		if err := s.config.Store.Delete(s.c, s.token); err != nil {
+			s.c.Engine().Logger.Printf("session: %v", err)
+		}
+		s.record = record{}
+		return false
+	}
+	if s.record.Values == nil {
+		s.record.Values = make(map[string]interface{})
+	}
+	return true
+}
+
+// newID returns a random session ID
+func newID() string {
+	id := make([]byte, 32)
+	if _, err := rand.Read(id); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(id)
+}
+
+// ID returns the session ID
+func (s *Session) ID() string {
+	return s.record.ID
+}
+
+// IsNew reports whether the session was created by this request
+func (s *Session) IsNew() bool {
+	return s.isNew
+}
+
+// Get returns the value of key, nil if it is not set
+func (s *Session) Get(key string) interface{} {
+	return s.record.Values[key]
+}
+
+// GetString returns the string value of key
+func (s *Session) GetString(key string) string {
+	value, _ := s.record.Values[key].(string)
+	return value
+}
+
+// GetInt returns the int value of key
+func (s *Session) GetInt(key string) int {
+	value, _ := s.record.Values[key].(int)
+	return value
+}
+
+// Set sets the value of key
+func (s *Session) Set(key string, value interface{}) {
+	s.record.Values[key] = value
+	s.modified = true
+}
+
+// Delete deletes the value of key
+func (s *Session) Delete(key string) {
+	if _, ok := s.record.Values[key]; ok {
+		delete(s.record.Values, key)
+		s.modified = true
+	}
+}
+
+// Regenerate changes the session ID and keeps the values, call it when the
+// privileges change, e.g. after login, against session fixation
+func (s *Session) Regenerate() {
+	if !s.isNew && s.oldToken == "" {
+		s.oldToken = s.token
+	}
+	s.record.ID = newID()
+	s.modified = true
+}
+
+// Destroy deletes the session and its cookie
+func (s *Session) Destroy() error {
+	s.destroyed = true
+	s.record.Values = make(map[string]interface{})
+	s.c.ClearCookie(s.config.CookieName)
+	for _, token := range []string{s.oldToken, s.token} {
+		if token == "" {
+			continue
+		}
+		if err := s.config.Store.Delete(s.c, token); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Save saves the session and sets the cookie if the session was modified,
+// or was not saved for a minute to extend the idle timeout. It is called by
+// the middleware before the response is sent, sessions saved afterwards do
+// not set the cookie.
+func (s *Session) Save() error {
+	if s.destroyed {
+		return nil
+	}
+	now := time.Now()
+	if !s.modified && now.Sub(s.record.Accessed) < touchInterval {
+		return nil
+	}
+	// sessions without values are not stored
+	if s.isNew && len(s.record.Values) == 0 {
+		return nil
+	}
+	if s.oldToken != "" {
+		if err := s.config.Store.Delete(s.c, s.oldToken); err != nil {
+			return err
+		}
+		s.oldToken = ""
+	}
+	s.record.Accessed = now
+	buf := new(bytes.Buffer)
+	if err := gob.NewEncoder(buf).Encode(&s.record); err != nil {
+		return err
+	}
+	expiry := now.Add(s.config.IdleTimeout)
+	if absolute := s.record.Created.Add(s.config.AbsoluteTimeout); absolute.Before(expiry) {
+		expiry = absolute
+	}
+	token, err := s.config.Store.Save(s.c, s.record.ID, buf.Bytes(), expiry)
+	if err != nil {
+		return err
+	}
+	s.token = token
+	s.isNew = false
+	s.modified = false
+	return s.c.Cookie(s.config.CookieName).Value(token).Expires(expiry).HttpOnly(true).Set()
+}
@@ -0,0 +1,310 @@
+package session
+
+import (
+	"bytes"
+	"encoding/gob"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/seefs001/seng"
+)
+
+var testKey = []byte("0123456789abcdef0123456789abcdef")
+
+// testStores returns the stores to test, in a new directory for FileStore
+func testStores(t *testing.T) map[string]Store {
+	files, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	memory := NewMemoryStore()
+	t.Cleanup(func() { memory.Close() })
+	return map[string]Store{"memory": memory, "file": files, "cookie": NewCookieStore()}
+}
+
+// newTestEngine returns an engine with the session middleware and handlers
+// reading and changing the session
+func newTestEngine(config Config) *seng.Engine {
+	e := seng.New(seng.Config{CookieKeys: [][]byte{testKey}})
+	e.Use(New(config))
+	e.GET("/get", func(c *seng.Context) error {
+		return c.Text(Get(c).GetString("user"))
+	})
+	e.GET("/set", func(c *seng.Context) error {
+		Get(c).Set("user", c.Query("user"))
+		return c.Text(Get(c).ID())
+	})
+	e.GET("/delete", func(c *seng.Context) error {
+		Get(c).Delete("user")
+		return nil
+	})
+	e.GET("/regenerate", func(c *seng.Context) error {
+		Get(c).Regenerate()
+		return nil
+	})
+	e.GET("/destroy", func(c *seng.Context) error {
+		return Get(c).Destroy()
+	})
+	return e
+}
+
+// request serves target with the session cookie token, it returns the body
+// and the Set-Cookie header of the session
+func request(e *seng.Engine, target, token string) (string, string) {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if token != "" {
+		req.Header.Set(seng.HeaderCookie, DefaultCookieName+"="+token)
+	}
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	for _, header := range w.Header()[seng.HeaderSetCookie] {
+		if strings.HasPrefix(header, DefaultCookieName+"=") {
+			return w.Body.String(), header
+		}
+	}
+	return w.Body.String(), ""
+}
+
+// cookieValue returns the value of a Set-Cookie header
+func cookieValue(header string) string {
+	return strings.TrimPrefix(strings.SplitN(header, ";", 2)[0], DefaultCookieName+"=")
+}
+
+// modifyToken returns token with another first character
+func modifyToken(token string) string {
+	if token[0] == 'x' {
+		return "y" + token[1:]
+	}
+	return "x" + token[1:]
+}
+
+func TestSession(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			e := newTestEngine(Config{Store: store})
+			// sessions without values are not stored
+			if _, header := request(e, "/get", ""); header != "" {
+				t.Fatalf("empty session Set-Cookie = %q", header)
+			}
+
+			_, header := request(e, "/set?user=ann", "")
+			for _, attribute := range []string{"HttpOnly", "Expires=", "Path=/"} {
+				if !strings.Contains(header, attribute) {
+					t.Errorf("Set-Cookie %q without %s", header, attribute)
+				}
+			}
+			token := cookieValue(header)
+			tests := []struct {
+				name  string
+				token string
+				want  string
+			}{
+				{"session cookie", token, "ann"},
+				{"no cookie", "", ""},
+				{"unknown token", "unknown", ""},
+				{"modified token", modifyToken(token), ""},
+				{"path traversal", "../" + token, ""},
+			}
+			for _, tt := range tests {
+				if got, _ := request(e, "/get", tt.token); got != tt.want {
+					t.Errorf("%s: user = %q, want %q", tt.name, got, tt.want)
+				}
+			}
+			// sessions which were just saved are not saved again
+			if _, header := request(e, "/get", token); header != "" {
+				t.Errorf("unmodified session Set-Cookie = %q", header)
+			}
+
+			_, header = request(e, "/delete", token)
+			if header == "" {
+				t.Fatal("Delete did not save the session")
+			}
+			if got, _ := request(e, "/get", cookieValue(header)); got != "" {
+				t.Errorf("user after Delete = %q", got)
+			}
+		})
+	}
+}
+
+func TestSessionRegenerateDestroy(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			e := newTestEngine(Config{Store: store})
+			_, header := request(e, "/set?user=ann", "")
+			token := cookieValue(header)
+
+			_, header = request(e, "/regenerate", token)
+			regenerated := cookieValue(header)
+			if regenerated == "" || regenerated == token {
+				t.Fatalf("Regenerate Set-Cookie = %q", header)
+			}
+			if got, _ := request(e, "/get", regenerated); got != "ann" {
+				t.Errorf("user after Regenerate = %q", got)
+			}
+			// cookie sessions cannot be revoked before they expire
+			_, cookieStore := store.(CookieStore)
+			if got, _ := request(e, "/get", token); !cookieStore && got != "" {
+				t.Errorf("user of the token before Regenerate = %q", got)
+			}
+
+			_, header = request(e, "/destroy", regenerated)
+			if !strings.HasPrefix(header, DefaultCookieName+"=;") || !strings.Contains(header, "Max-Age=0") {
+				t.Errorf("Destroy Set-Cookie = %q", header)
+			}
+			if got, _ := request(e, "/get", regenerated); !cookieStore && got != "" {
+				t.Errorf("user after Destroy = %q", got)
+			}
+		})
+	}
+}
+
+func TestSessionTimeouts(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name     string
+		created  time.Time
+		accessed time.Time
+		want     string
+	}{
+		{"active", now.Add(-time.Hour), now.Add(-time.Minute), "ann"},
+		{"idle", now.Add(-time.Hour), now.Add(-DefaultIdleTimeout - time.Minute), ""},
+		{"absolute", now.Add(-DefaultAbsoluteTimeout - time.Minute), now.Add(-time.Minute), ""},
+	}
+	for name, store := range testStores(t) {
+		for _, tt := range tests {
+			t.Run(name+" "+tt.name, func(t *testing.T) {
+				e := newTestEngine(Config{Store: store})
+				var token string
+				// store a session as if it was created and used earlier
+				e.GET("/store", func(c *seng.Context) error {
+					buf := new(bytes.Buffer)
+					r := record{ID: newID(), Created: tt.created, Accessed: tt.accessed, Values: map[string]interface{}{"user": "ann"}}
+					if err := gob.NewEncoder(buf).Encode(&r); err != nil {
+						return err
+					}
+					var err error
+					token, err = store.Save(c, r.ID, buf.Bytes(), now.Add(time.Hour))
+					return err
+				})
+				request(e, "/store", "")
+				if got, _ := request(e, "/get", token); got != tt.want {
+					t.Errorf("user = %q, want %q", got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestStores(t *testing.T) {
+	tests := []struct {
+		name   string
+		expiry time.Time
+		delete bool
+		want   string
+	}{
+		{"stored", time.Now().Add(time.Hour), false, "data"},
+		{"expired", time.Now().Add(-time.Second), false, ""},
+		{"deleted", time.Now().Add(time.Hour), true, ""},
+	}
+	for name, store := range testStores(t) {
+		for _, tt := range tests {
+			withContext(func(c *seng.Context) {
+				testStore(t, c, name+" "+tt.name, store, tt.expiry, tt.delete, tt.want)
+			})
+		}
+	}
+}
+
+// withContext calls fn with the Context of a request
+func withContext(fn func(c *seng.Context)) {
+	e := seng.New(seng.Config{CookieKeys: [][]byte{testKey}})
+	e.GET("/", func(c *seng.Context) error {
+		fn(c)
+		return nil
+	})
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+// testStore saves data until expiry, deletes it if deleted and checks that
+// store loads want
+func testStore(t *testing.T, c *seng.Context, name string, store Store, expiry time.Time, deleted bool, want string) {
+	token, err := store.Save(c, newID(), []byte("data"), expiry)
+	if err != nil {
+		t.Fatalf("%s: Save: %v", name, err)
+	}
+	if deleted {
+		if err := store.Delete(c, token); err != nil {
+			t.Fatalf("%s: Delete: %v", name, err)
+		}
+	}
+	data, err := store.Load(c, token)
+	// cookie sessions cannot be deleted
+	if _, ok := store.(CookieStore); ok && deleted {
+		return
+	}
+	if err != nil || string(data) != want {
+		t.Errorf("%s: Load = %q, %v, want %q", name, data, err, want)
+	}
+}
+
+func TestFileStoreGC(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id, expiry := range map[string]time.Time{"active": time.Now().Add(time.Hour), "expired": time.Now().Add(-time.Second)} {
+		if _, err := store.Save(nil, id, []byte(id), expiry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := store.Save(nil, "../escape", nil, time.Now()); err == nil {
+		t.Error("Save of an invalid ID succeeded")
+	}
+	if err := store.GC(); err != nil {
+		t.Fatal(err)
+	}
+	for id, want := range map[string]bool{"active": true, "expired": false} {
+		path, _ := store.path(id)
+		if _, err := os.Stat(path); (err == nil) != want {
+			t.Errorf("%s: file exists %v, want %v", id, err == nil, want)
+		}
+	}
+}
+
+func TestMemoryStoreGC(t *testing.T) {
+	store := NewMemoryStore(10 * time.Millisecond)
+	defer store.Close()
+	for id, expiry := range map[string]time.Time{"active": time.Now().Add(time.Hour), "expired": time.Now().Add(-time.Second)} {
+		if _, err := store.Save(nil, id, []byte(id), expiry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deadline := time.Now().Add(time.Second)
+	for store.Len() != 1 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if store.Len() != 1 {
+		t.Errorf("Len after gc = %d, want 1", store.Len())
+	}
+	if err := store.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCookieStoreTooLarge(t *testing.T) {
+	e := newTestEngine(Config{Store: NewCookieStore()})
+	var err error
+	e.GET("/large", func(c *seng.Context) error {
+		Get(c).Set("user", strings.Repeat("a", MaxCookieSize))
+		err = Get(c).Save()
+		return nil
+	})
+	_, header := request(e, "/large", "")
+	if err != ErrCookieTooLarge || header != "" {
+		t.Errorf("Save = %v, Set-Cookie %q, want ErrCookieTooLarge", err, header)
+	}
+}
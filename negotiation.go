@@ -0,0 +1,278 @@
+package seng
+
+import (
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FormatDefault is the key of the Format handler used when no type is acceptable
+const FormatDefault = "default"
+
+// acceptSpec a value of an Accept-* header with its quality and parameters
+type acceptSpec struct {
+	value  string
+	q      float64
+	params map[string]string
+}
+
+// parseAccept parses an Accept-* header, values are sorted by quality
+// "en;q=0.8, zh-CN" -> [zh-CN en]
+func parseAccept(header string) []acceptSpec {
+	if header == "" {
+		return nil
+	}
+	parts := strings.Split(header, ",")
+	specs := make([]acceptSpec, 0, len(parts))
+	for _, part := range parts {
+		params := strings.Split(part, ";")
+		spec := acceptSpec{value: strings.ToLower(strings.TrimSpace(params[0])), q: 1}
+		if spec.value == "" {
+			continue
+		}
+		for _, param := range params[1:] {
+			kv := strings.SplitN(param, "=", 2)
+			if len(kv) != 2 {
+				continue
+			}
+			key := strings.ToLower(strings.TrimSpace(kv[0]))
+			value := strings.Trim(strings.TrimSpace(kv[1]), `"`)
+			if key == "q" {
+				q, err := strconv.ParseFloat(value, 64)
+				if err != nil || q < 0 || q > 1 {
+					q = 0
+				}
+				spec.q = q
+				// parameters after q are accept-ext
+				break
+			}
+			if spec.params == nil {
+				spec.params = make(map[string]string)
+			}
+			spec.params[key] = value
+		}
+		specs = append(specs, spec)
+	}
+	sort.SliceStable(specs, func(i, j int) bool {
+		return specs[i].q > specs[j].q
+	})
+	return specs
+}
+
+// matchFunc returns the specificity of spec for offer, -1 if it does not match
+type matchFunc func(spec acceptSpec, offer string) int
+
+// negotiate returns the index of the offer with the highest quality, using the
+// most specific spec matching each offer. Ties go to the first offer, -1 if no
+// offer is acceptable. Without specs the first offer is returned.
+func negotiate(specs []acceptSpec, offers []string, match matchFunc) int {
+	if len(offers) == 0 {
+		return -1
+	}
+	if len(specs) == 0 {
+		return 0
+	}
+	best, bestQ := -1, 0.0
+	for i, offer := range offers {
+		q, specificity := 0.0, -1
+		for _, spec := range specs {
+			if s := match(spec, offer); s > specificity {
+				q, specificity = spec.q, s
+			}
+		}
+		if q > bestQ {
+			best, bestQ = i, q
+		}
+	}
+	return best
+}
+
+// matchMediaType text/html;level=1 > text/html > text/* > */*
+func matchMediaType(spec acceptSpec, offer string) int {
+	offerType, offerParams, err := mime.ParseMediaType(offer)
+	if err != nil {
+		return -1
+	}
+	specType, specSub := splitMediaType(spec.value)
+	typ, sub := splitMediaType(offerType)
+	specificity := 0
+	switch {
+	case specType == "*" && specSub == "*":
+	case specType == typ && specSub == "*":
+		specificity = 1
+	case specType == typ && specSub == sub:
+		specificity = 2
+	default:
+		return -1
+	}
+	for key, value := range spec.params {
+		if !strings.EqualFold(offerParams[key], value) {
+			return -1
+		}
+	}
+	return specificity + len(spec.params)
+}
+
+// splitMediaType text/html -> text, html
+func splitMediaType(mediaType string) (string, string) {
+	parts := strings.SplitN(mediaType, "/", 2)
+	if len(parts) != 2 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
+
+// matchToken gzip > *, used by encodings and charsets
+func matchToken(spec acceptSpec, offer string) int {
+	switch {
+	case strings.EqualFold(spec.value, offer):
+		return 1
+	case spec.value == "*":
+		return 0
+	}
+	return -1
+}
+
+// matchLanguage en-US > en > *
+func matchLanguage(spec acceptSpec, offer string) int {
+	offer = normalizeLocale(offer)
+	value := normalizeLocale(spec.value)
+	switch {
+	case value == offer:
+		return 3
+	case languagePrefix(value) == offer:
+		return 2
+	case value == languagePrefix(offer):
+		return 1
+	case value == "*":
+		return 0
+	}
+	return -1
+}
+
+// languagePrefix en-us -> en
+func languagePrefix(language string) string {
+	if i := strings.IndexByte(language, '-'); i > 0 {
+		return language[:i]
+	}
+	return language
+}
+
+// mediaTypeOf json -> application/json, text/html -> text/html
+func mediaTypeOf(offer string) string {
+	if strings.Contains(offer, "/") {
+		return offer
+	}
+	mediaType := mime.TypeByExtension("." + offer)
+	if mediaType == "" {
+		return offer
+	}
+	// drop ;charset=utf-8
+	if i := strings.IndexByte(mediaType, ';'); i > 0 {
+		mediaType = mediaType[:i]
+	}
+	return mediaType
+}
+
+// negotiateMediaType returns the best of offers for the Accept header
+func negotiateMediaType(accept string, offers []string) string {
+	mediaTypes := make([]string, len(offers))
+	for i, offer := range offers {
+		mediaTypes[i] = mediaTypeOf(offer)
+	}
+	if i := negotiate(parseAccept(accept), mediaTypes, matchMediaType); i >= 0 {
+		return offers[i]
+	}
+	return ""
+}
+
+// Accepts returns the best of offers for the Accept header by RFC 9110
+// q-values, or "" if none is acceptable.
+// Offers are media types or extensions, ties go to the first offer.
+// c.Accepts("json", "text/html")
+func (c *Context) Accepts(offers ...string) string {
+	return negotiateMediaType(c.GetHeader(HeaderAccept), offers)
+}
+
+// AcceptsEncodings returns the best of offers for the Accept-Encoding header,
+// identity is acceptable unless excluded.
+// c.AcceptsEncodings("br", "gzip", "identity")
+func (c *Context) AcceptsEncodings(offers ...string) string {
+	specs := parseAccept(c.GetHeader(HeaderAcceptEncoding))
+	explicit := false
+	for _, spec := range specs {
+		if spec.value == "identity" || spec.value == "*" {
+			explicit = true
+		}
+	}
+	if len(specs) > 0 && !explicit {
+		specs = append(specs, acceptSpec{value: "identity", q: 1})
+	}
+	if i := negotiate(specs, offers, matchToken); i >= 0 {
+		return offers[i]
+	}
+	return ""
+}
+
+// AcceptsCharsets returns the best of offers for the Accept-Charset header
+func (c *Context) AcceptsCharsets(offers ...string) string {
+	if i := negotiate(parseAccept(c.GetHeader(HeaderAcceptCharset)), offers, matchToken); i >= 0 {
+		return offers[i]
+	}
+	return ""
+}
+
+// AcceptsLanguages returns the best of offers for the Accept-Language header,
+// en-US matches an en offer and en matches an en-US offer.
+// c.AcceptsLanguages("en", "zh-CN")
+func (c *Context) AcceptsLanguages(offers ...string) string {
+	if i := negotiate(parseAccept(c.GetHeader(HeaderAcceptLanguage)), offers, matchLanguage); i >= 0 {
+		return offers[i]
+	}
+	return ""
+}
+
+// Format calls the handler of the best media type for the Accept header and
+// sets it as Content-Type. Keys are offers as for Accepts, ties go to the first
+// key in sorted order. The FormatDefault handler is called when no type is
+// acceptable, without it Format returns a 406 Not Acceptable error.
+//
+//	c.Format(map[string]func() error{
+//		"json": func() error { return c.JSON(data) },
+//		"html": func() error { return c.HTML("pet", data) },
+//	})
+func (c *Context) Format(handlers map[string]func() error) error {
+	offers := make([]string, 0, len(handlers))
+	for offer := range handlers {
+		if offer != FormatDefault {
+			offers = append(offers, offer)
+		}
+	}
+	sort.Strings(offers)
+	c.Writer.Header().Add(HeaderVary, HeaderAccept)
+	if offer := c.Accepts(offers...); offer != "" {
+		c.Writer.Header().Set(HeaderContentType, mediaTypeOf(offer))
+		return handlers[offer]()
+	}
+	if handler, ok := handlers[FormatDefault]; ok {
+		return handler()
+	}
+	return NewError(http.StatusNotAcceptable)
+}
+
+// Negotiate renders data with status code as JSON, XML or YAML by the Accept
+// header, or returns a 406 Not Acceptable error
+func (c *Context) Negotiate(code int, data interface{}) error {
+	c.Writer.Header().Add(HeaderVary, HeaderAccept)
+	switch c.Accepts(ContentTypeJson, ContentTypeXml, ContentTypeYaml, "application/x-yaml", "text/yaml") {
+	case ContentTypeJson:
+		return c.Status(code).JSON(data)
+	case ContentTypeXml:
+		return c.Status(code).XML(data)
+	case ContentTypeYaml, "application/x-yaml", "text/yaml":
+		return c.Status(code).YAML(data)
+	}
+	return NewError(http.StatusNotAcceptable)
+}
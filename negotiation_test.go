@@ -0,0 +1,185 @@
+package seng
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newTestContext returns a Context without engine for the request headers
+func newTestContext(headers ...string) *Context {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	return NewContext(httptest.NewRecorder(), req)
+}
+
+func TestParseAccept(t *testing.T) {
+	specs := parseAccept(`text/html;level=1;q=0.5, application/json, */*;q=0.1, text/plain;q=x, ,image/png;q="0.8"`)
+	want := []struct {
+		value string
+		q     float64
+	}{
+		{"application/json", 1},
+		{"image/png", 0.8},
+		{"text/html", 0.5},
+		{"*/*", 0.1},
+		{"text/plain", 0},
+	}
+	if len(specs) != len(want) {
+		t.Fatalf("parseAccept = %+v", specs)
+	}
+	for i, spec := range specs {
+		if spec.value != want[i].value || spec.q != want[i].q {
+			t.Errorf("spec %d = %s;q=%v, want %s;q=%v", i, spec.value, spec.q, want[i].value, want[i].q)
+		}
+	}
+	if specs[2].params["level"] != "1" {
+		t.Errorf("params = %v, want level=1", specs[2].params)
+	}
+}
+
+func TestAccepts(t *testing.T) {
+	tests := []struct {
+		accept string
+		offers []string
+		want   string
+	}{
+		{"", []string{"json", "html"}, "json"},
+		{"text/html", []string{"json", "html"}, "html"},
+		{"application/json;q=0.5, text/html", []string{"json", "html"}, "html"},
+		{"text/*", []string{"application/json", "text/plain"}, "text/plain"},
+		{"*/*", []string{"xml", "json"}, "xml"},
+		{"text/*;q=0.5, text/html", []string{"text/plain", "text/html"}, "text/html"},
+		{"text/html;q=0, */*", []string{"text/html", "json"}, "json"},
+		{"text/html;level=1", []string{"text/html"}, ""},
+		{"text/html;level=1", []string{"text/html;level=1"}, "text/html;level=1"},
+		{"image/png", []string{"json", "html"}, ""},
+		{"application/json, text/html", []string{"html", "json"}, "html"},
+	}
+	for _, tt := range tests {
+		c := newTestContext(HeaderAccept, tt.accept)
+		if got := c.Accepts(tt.offers...); got != tt.want {
+			t.Errorf("Accept %q, Accepts(%v) = %q, want %q", tt.accept, tt.offers, got, tt.want)
+		}
+	}
+}
+
+func TestAcceptsEncodingsCharsetsLanguages(t *testing.T) {
+	encodings := (*Context).AcceptsEncodings
+	charsets := (*Context).AcceptsCharsets
+	languages := (*Context).AcceptsLanguages
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		accept func(c *Context, offers ...string) string
+		offers []string
+		want   string
+	}{
+		{"encoding", HeaderAcceptEncoding, "gzip, br", encodings, []string{"br", "gzip"}, "br"},
+		{"encoding q", HeaderAcceptEncoding, "gzip;q=1, br;q=0.5", encodings, []string{"br", "gzip"}, "gzip"},
+		{"identity implied", HeaderAcceptEncoding, "br", encodings, []string{"gzip", "identity"}, "identity"},
+		{"identity excluded", HeaderAcceptEncoding, "gzip, identity;q=0", encodings, []string{"br", "identity"}, ""},
+		{"identity excluded by star", HeaderAcceptEncoding, "*;q=0", encodings, []string{"identity"}, ""},
+		{"charset", HeaderAcceptCharset, "iso-8859-1, utf-8;q=0.9", charsets, []string{"utf-8", "iso-8859-1"}, "iso-8859-1"},
+		{"charset star", HeaderAcceptCharset, "*", charsets, []string{"utf-8"}, "utf-8"},
+		{"language region", HeaderAcceptLanguage, "en-US", languages, []string{"zh", "en"}, "en"},
+		{"language prefix", HeaderAcceptLanguage, "en", languages, []string{"zh-CN", "en-GB"}, "en-GB"},
+		{"language q", HeaderAcceptLanguage, "fr;q=0.5, zh-CN", languages, []string{"fr", "zh-CN"}, "zh-CN"},
+		{"language none", HeaderAcceptLanguage, "de", languages, []string{"fr", "zh-CN"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestContext(tt.header, tt.value)
+			if got := tt.accept(c, tt.offers...); got != tt.want {
+				t.Errorf("%s %q: %v -> %q, want %q", tt.header, tt.value, tt.offers, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocales(t *testing.T) {
+	c := newTestContext(HeaderAcceptLanguage, "fr;q=0.5, zh-CN, *;q=0.1, de;q=0")
+	if got := c.Locales(); len(got) != 2 || got[0] != "zh-cn" || got[1] != "fr" {
+		t.Errorf("Locales = %v, want [zh-cn fr]", got)
+	}
+	c.SetLocale("ja")
+	if got := c.Locale(); got != "ja" {
+		t.Errorf("Locale after SetLocale = %q", got)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		accept      string
+		withDefault bool
+		want        string
+		wantType    string
+	}{
+		{"application/json", false, "json", "application/json"},
+		{"text/html, application/json;q=0.9", false, "html", "text/html"},
+		{"", false, "html", "text/html"},
+		{"image/png", true, "default", ""},
+		{"image/png", false, "", ""},
+	}
+	for _, tt := range tests {
+		c := newTestContext(HeaderAccept, tt.accept)
+		var got string
+		handlers := map[string]func() error{
+			"json": func() error { got = "json"; return nil },
+			"html": func() error { got = "html"; return nil },
+		}
+		if tt.withDefault {
+			handlers[FormatDefault] = func() error { got = "default"; return nil }
+		}
+		err := c.Format(handlers)
+		if tt.want == "" {
+			if e, ok := err.(*Error); !ok || e.Code != http.StatusNotAcceptable {
+				t.Errorf("Accept %q: Format = %v, want 406", tt.accept, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("Accept %q: called %q (%v), want %q", tt.accept, got, err, tt.want)
+		}
+		if ct := c.Writer.Header().Get(HeaderContentType); ct != tt.wantType {
+			t.Errorf("Accept %q: Content-Type %q, want %q", tt.accept, ct, tt.wantType)
+		}
+		if c.Writer.Header().Get(HeaderVary) != HeaderAccept {
+			t.Errorf("Accept %q: no Vary", tt.accept)
+		}
+	}
+}
+
+type negotiated struct {
+	A int `json:"a" xml:"a"`
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		accept   string
+		wantCode int
+		wantType string
+	}{
+		{"application/json", http.StatusCreated, MINEApplicationJSON},
+		{"application/xml", http.StatusCreated, "application/xml"},
+		{"text/yaml", http.StatusCreated, "yaml"},
+		{"image/png", http.StatusNotAcceptable, ""},
+	}
+	for _, tt := range tests {
+		e := New()
+		e.GET("/", func(c *Context) error {
+			return c.Negotiate(http.StatusCreated, negotiated{A: 1})
+		})
+		w := serve(t, e, http.MethodGet, "/", "", HeaderAccept, tt.accept)
+		if w.Code != tt.wantCode {
+			t.Errorf("Accept %q: status %d, want %d", tt.accept, w.Code, tt.wantCode)
+		}
+		if ct := w.Header().Get(HeaderContentType); !strings.Contains(ct, tt.wantType) {
+			t.Errorf("Accept %q: Content-Type %q, want %q", tt.accept, ct, tt.wantType)
+		}
+	}
+}
@@ -2,25 +2,199 @@ package seng
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io/ioutil"
+	"reflect"
+	"strconv"
 	"strings"
 )
 
-// BodyParser parser struct application/json
+// binding tags
+const (
+	ParamTag = "param"
+	QueryTag = "query"
+	FormTag  = "form"
+)
+
+// BodyParser parser struct application/json application/x-www-form-urlencoded
 func (c *Context) BodyParser(out interface{}) (err error) {
 	contentType := strings.ToLower(c.GetContentType())
 	// json parser
-	if strings.HasPrefix(contentType, MINEApplicationJSON) {
+	if strings.HasPrefix(contentType, ContentTypeJson) {
 		// read data from request
 		data, err := ioutil.ReadAll(c.Request.Body)
 		if err != nil {
 			return err
 		}
+		// empty body keeps the zero value
+		if len(data) == 0 {
+			return nil
+		}
 		err = json.Unmarshal(data, out)
 		if err != nil {
 			return err
 		}
 	}
-	//TODO form parser,query parser
-	return
+	// form parser
+	if strings.HasPrefix(contentType, ContentTypeForm) || strings.HasPrefix(contentType, ContentTypeMultipartForm) {
+		if strings.HasPrefix(contentType, ContentTypeMultipartForm) {
+			err = c.Request.ParseMultipartForm(int64(c.bodyLimit()))
+		} else {
+			err = c.Request.ParseForm()
+		}
+		if err != nil {
+			return err
+		}
+		return bindValues(out, FormTag, func(key string) ([]string, bool) {
+			values, ok := c.Request.PostForm[key]
+			return values, ok
+		})
+	}
+	return nil
+}
+
+// QueryParser binds the query string to the `query` tagged fields of out
+func (c *Context) QueryParser(out interface{}) error {
+	query := c.Request.URL.Query()
+	return bindValues(out, QueryTag, func(key string) ([]string, bool) {
+		values, ok := query[key]
+		return values, ok
+	})
+}
+
+// ParamsParser binds the route parameters to the `param` tagged fields of out
+func (c *Context) ParamsParser(out interface{}) error {
+	return bindValues(out, ParamTag, func(key string) ([]string, bool) {
+		value, ok := c.Params[key]
+		return []string{value}, ok
+	})
+}
+
+// Bind binds query string, body and route parameters to out, in that order.
+// Route parameters are bound last so that the body cannot override them,
+// e.g. the id of PUT /pets/:id.
+func (c *Context) Bind(out interface{}) error {
+	if err := c.QueryParser(out); err != nil {
+		return err
+	}
+	if err := c.BodyParser(out); err != nil {
+		return err
+	}
+	return c.ParamsParser(out)
+}
+
+// bodyLimit returns the configured body limit
+func (c *Context) bodyLimit() int {
+	if c.engine == nil || c.engine.config.BodyLimit == 0 {
+		return DefaultBodyLimit
+	}
+	return c.engine.config.BodyLimit
+}
+
+// bindValues sets every field of the struct pointed to by out whose tag
+// matches a key returned by lookup
+func bindValues(out interface{}, tag string, lookup func(key string) ([]string, bool)) error {
+	v := reflect.ValueOf(out)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return errors.New("seng: bind target must be a non-nil pointer")
+	}
+	v = v.Elem()
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	return bindStruct(v, tag, lookup)
+}
+
+func bindStruct(v reflect.Value, tag string, lookup func(key string) ([]string, bool)) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		// unexported
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+		fv := v.Field(i)
+		name, ok := field.Tag.Lookup(tag)
+		if !ok {
+			// embedded struct
+			if field.Anonymous && fv.Kind() == reflect.Struct {
+				if err := bindStruct(fv, tag, lookup); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		name = strings.Split(name, ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		values, ok := lookup(name)
+		if !ok || len(values) == 0 {
+			continue
+		}
+		if err := setField(fv, values); err != nil {
+			return fmt.Errorf("seng: %s %q: %v", tag, name, err)
+		}
+	}
+	return nil
+}
+
+// setField converts values to the type of fv
+func setField(fv reflect.Value, values []string) error {
+	if fv.Kind() == reflect.Ptr {
+		if fv.IsNil() {
+			fv.Set(reflect.New(fv.Type().Elem()))
+		}
+		return setField(fv.Elem(), values)
+	}
+	if fv.Kind() == reflect.Slice {
+		slice := reflect.MakeSlice(fv.Type(), len(values), len(values))
+		for i, value := range values {
+			if err := setValue(slice.Index(i), value); err != nil {
+				return err
+			}
+		}
+		fv.Set(slice)
+		return nil
+	}
+	return setValue(fv, values[0])
+}
+
+// setValue converts a single string to the kind of fv
+func setValue(fv reflect.Value, value string) error {
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(value, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported kind %s", fv.Kind())
+	}
+	return nil
 }
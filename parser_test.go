@@ -0,0 +1,78 @@
+package seng
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestBindPrecedence(t *testing.T) {
+	type request struct {
+		ID   int64  `param:"id" json:"id"`
+		Tag  string `query:"tag" json:"tag"`
+		Name string `json:"name"`
+	}
+	tests := []struct {
+		name   string
+		target string
+		body   string
+		want   request
+	}{
+		{"params", "/pets/1", `{"name":"rex"}`, request{ID: 1, Name: "rex"}},
+		{"body cannot override params", "/pets/1", `{"id":999,"name":"rex"}`, request{ID: 1, Name: "rex"}},
+		{"query", "/pets/1?tag=dog", ``, request{ID: 1, Tag: "dog"}},
+		{"body overrides query", "/pets/1?tag=dog", `{"tag":"cat"}`, request{ID: 1, Tag: "cat"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New()
+			var got request
+			e.PUT("/pets/:id", func(c *Context) error {
+				return c.Bind(&got)
+			})
+			w := serve(t, e, http.MethodPut, tt.target, tt.body, HeaderContentType, ContentTypeJson)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, body %q", w.Code, w.Body)
+			}
+			if got != tt.want {
+				t.Errorf("Bind = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBindValues(t *testing.T) {
+	type request struct {
+		Page  int      `query:"page"`
+		Tags  []string `query:"tag"`
+		Ratio *float64 `query:"ratio"`
+		On    bool     `query:"on"`
+	}
+	tests := []struct {
+		query   string
+		check   func(r request) bool
+		wantErr bool
+	}{
+		{"page=2", func(r request) bool { return r.Page == 2 }, false},
+		{"tag=a&tag=b", func(r request) bool { return len(r.Tags) == 2 && r.Tags[1] == "b" }, false},
+		{"ratio=0.5", func(r request) bool { return r.Ratio != nil && *r.Ratio == 0.5 }, false},
+		{"on=true", func(r request) bool { return r.On }, false},
+		{"page=x", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			var got request
+			query, _ := url.ParseQuery(tt.query)
+			err := bindValues(&got, QueryTag, func(key string) ([]string, bool) {
+				values, ok := query[key]
+				return values, ok
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %t", err, tt.wantErr)
+			}
+			if tt.check != nil && !tt.check(got) {
+				t.Errorf("bound %+v", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package seng
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Redirect redirects to location with status code 300, 301, 302, 303, 307 or 308.
+// Locations on other hosts are refused unless in Config.RedirectAllowedHosts.
+// return c.Redirect(http.StatusSeeOther, "/login")
+func (c *Context) Redirect(code int, location string) error {
+	if !isRedirectCode(code) {
+		return fmt.Errorf("seng: invalid redirect status code %d", code)
+	}
+	if !c.isSafeRedirect(location) {
+		return NewError(http.StatusBadRequest, "seng: redirect to an external host")
+	}
+	http.Redirect(c.Writer, c.Request, location, code)
+	return nil
+}
+
+// RedirectBack redirects to the Referer, or to fallback when the Referer is
+// missing or on another host. The status code is 302 Found by default.
+func (c *Context) RedirectBack(fallback string, code ...int) error {
+	status := http.StatusFound
+	if len(code) > 0 {
+		status = code[0]
+	}
+	location := c.Refer()
+	if location == "" || !c.isSafeRedirect(location) {
+		location = fallback
+	}
+	return c.Redirect(status, location)
+}
+
+// RedirectRoute redirects to the named route built from params, see Engine.URL
+// return c.RedirectRoute(http.StatusSeeOther, "pet", "id", pet.ID)
+func (c *Context) RedirectRoute(code int, name string, params ...interface{}) error {
+	location, err := c.engine.URL(name, params...)
+	if err != nil {
+		return err
+	}
+	return c.Redirect(code, location)
+}
+
+// isRedirectCode reports whether code redirects with a Location header,
+// 304 Not Modified, 305 Use Proxy and the unused 306 do not
+func isRedirectCode(code int) bool {
+	switch code {
+	case http.StatusMultipleChoices, http.StatusMovedPermanently, http.StatusFound,
+		http.StatusSeeOther, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
+
+// isSafeRedirect allows relative locations, the request host and Config.RedirectAllowedHosts
+func (c *Context) isSafeRedirect(location string) bool {
+	// browsers treat backslashes as slashes, "/\evil.com" is protocol-relative
+	location = strings.Replace(location, `\`, "/", -1)
+	u, err := url.Parse(location)
+	if err != nil {
+		return false
+	}
+	if u.Scheme == "" && u.Host == "" && !strings.HasPrefix(location, "//") {
+		return true
+	}
+	if u.Scheme != "" && u.Scheme != "http" && u.Scheme != "https" {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	if host == "" {
+		return false
+	}
+	if strings.EqualFold(u.Host, c.Request.Host) {
+		return true
+	}
+	if c.engine == nil {
+		return false
+	}
+	for _, allowed := range c.engine.config.RedirectAllowedHosts {
+		allowed = strings.ToLower(allowed)
+		if host == allowed ||
+			strings.HasPrefix(allowed, "*.") && strings.HasSuffix(host, allowed[1:]) {
+			return true
+		}
+	}
+	return false
+}
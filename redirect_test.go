@@ -0,0 +1,104 @@
+package seng
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestRedirect(t *testing.T) {
+	tests := []struct {
+		name         string
+		code         int
+		location     string
+		wantCode     int
+		wantLocation string
+	}{
+		{"multiple choices", http.StatusMultipleChoices, "/a", http.StatusMultipleChoices, "/a"},
+		{"moved permanently", http.StatusMovedPermanently, "/a", http.StatusMovedPermanently, "/a"},
+		{"found", http.StatusFound, "/a", http.StatusFound, "/a"},
+		{"see other", http.StatusSeeOther, "/a?b=c", http.StatusSeeOther, "/a?b=c"},
+		{"temporary", http.StatusTemporaryRedirect, "/a", http.StatusTemporaryRedirect, "/a"},
+		{"permanent", http.StatusPermanentRedirect, "/a", http.StatusPermanentRedirect, "/a"},
+		{"not modified", http.StatusNotModified, "/a", http.StatusInternalServerError, ""},
+		{"use proxy", http.StatusUseProxy, "/a", http.StatusInternalServerError, ""},
+		{"unused 306", 306, "/a", http.StatusInternalServerError, ""},
+		{"ok", http.StatusOK, "/a", http.StatusInternalServerError, ""},
+		{"same host", http.StatusFound, "http://example.com/a", http.StatusFound, "http://example.com/a"},
+		{"allowed host", http.StatusFound, "https://accounts.example.org/login", http.StatusFound, "https://accounts.example.org/login"},
+		{"allowed subdomain", http.StatusFound, "https://a.cdn.example.org/x", http.StatusFound, "https://a.cdn.example.org/x"},
+		{"wildcard is not the apex", http.StatusFound, "https://cdn.example.org/x", http.StatusBadRequest, ""},
+		{"external host", http.StatusFound, "https://evil.example/", http.StatusBadRequest, ""},
+		{"protocol relative", http.StatusFound, "//evil.example/", http.StatusBadRequest, ""},
+		{"backslash", http.StatusFound, `/\evil.example/`, http.StatusBadRequest, ""},
+		{"javascript", http.StatusFound, "javascript:alert(1)", http.StatusBadRequest, ""},
+	}
+	e := New(Config{RedirectAllowedHosts: []string{"Accounts.example.org", "*.cdn.example.org"}})
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := "/" + strconv.Itoa(i)
+			e.GET(path, func(c *Context) error {
+				return c.Redirect(tt.code, tt.location)
+			})
+			w := serve(t, e, http.MethodGet, path, "")
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantCode)
+			}
+			if got := w.Header().Get("Location"); got != tt.wantLocation {
+				t.Errorf("Location = %q, want %q", got, tt.wantLocation)
+			}
+		})
+	}
+}
+
+func TestRedirectBack(t *testing.T) {
+	tests := []struct {
+		name         string
+		referer      string
+		code         []int
+		wantCode     int
+		wantLocation string
+	}{
+		{"referer", "http://example.com/pets?page=2", nil, http.StatusFound, "http://example.com/pets?page=2"},
+		{"no referer", "", nil, http.StatusFound, "/home"},
+		{"external referer", "https://evil.example/", nil, http.StatusFound, "/home"},
+		{"status", "", []int{http.StatusSeeOther}, http.StatusSeeOther, "/home"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New()
+			e.GET("/", func(c *Context) error {
+				return c.RedirectBack("/home", tt.code...)
+			})
+			var headers []string
+			if tt.referer != "" {
+				headers = []string{"Referer", tt.referer}
+			}
+			w := serve(t, e, http.MethodGet, "/", "", headers...)
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantCode)
+			}
+			if got := w.Header().Get("Location"); got != tt.wantLocation {
+				t.Errorf("Location = %q, want %q", got, tt.wantLocation)
+			}
+		})
+	}
+}
+
+func TestRedirectRoute(t *testing.T) {
+	e := New()
+	e.GET("/pets/:id", func(c *Context) error { return nil }).Named("pet")
+	e.POST("/pets", func(c *Context) error {
+		return c.RedirectRoute(http.StatusSeeOther, "pet", "id", 7)
+	})
+	e.POST("/unknown", func(c *Context) error {
+		return c.RedirectRoute(http.StatusSeeOther, "missing")
+	})
+	w := serve(t, e, http.MethodPost, "/pets", "")
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/pets/7" {
+		t.Errorf("redirect = %d %q", w.Code, w.Header().Get("Location"))
+	}
+	if w := serve(t, e, http.MethodPost, "/unknown", ""); w.Code != http.StatusInternalServerError {
+		t.Errorf("unknown route status = %d", w.Code)
+	}
+}
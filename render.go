@@ -5,7 +5,11 @@ import (
 	"bytes"
 	"encoding/json"
 	"encoding/xml"
+	"io"
 	"net/http"
+	"regexp"
+	"strconv"
+	"unicode/utf8"
 )
 
 type HTTPResponse struct {
@@ -15,6 +19,10 @@ type HTTPResponse struct {
 }
 
 func NewHTTPResponse(code int, err interface{}) HTTPResponse {
+	// errors have no exported fields
+	if e, ok := err.(error); ok {
+		err = e.Error()
+	}
 	r := HTTPResponse{
 		Code:   code,
 		Status: http.StatusText(code),
@@ -23,54 +31,284 @@ func NewHTTPResponse(code int, err interface{}) HTTPResponse {
 	return r
 }
 
+// writeContent sets the content type then writes body
+func (c *Context) writeContent(contentType string, body []byte) error {
+	c.Writer.Header().Set(HeaderContentType, contentType)
+	return c.Data(body)
+}
+
+// debug reports whether the engine is in debug mode
+func (c *Context) debug() bool {
+	return c.engine != nil && c.engine.config.Debug
+}
+
+// IndentedJSON return indented JSON
+func (c *Context) IndentedJSON(obj interface{}) error {
+	body, err := json.MarshalIndent(obj, "", "    ")
+	if err != nil {
+		return err
+	}
+	return c.writeContent(MINEApplicationJSON, body)
+}
+
+// AsciiJSON return JSON with non-ASCII characters escaped as \uXXXX
+func (c *Context) AsciiJSON(obj interface{}) error {
+	body, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	buf := bytes.NewBuffer(make([]byte, 0, len(body)))
+	for _, r := range string(body) {
+		switch {
+		case r < utf8.RuneSelf:
+			buf.WriteByte(byte(r))
+		case r > 0xFFFF:
+			// surrogate pair
+			r -= 0x10000
+			buf.WriteString(`\u` + strconv.FormatInt(int64(0xD800+(r>>10)), 16))
+			buf.WriteString(`\u` + strconv.FormatInt(int64(0xDC00+(r&0x3FF)), 16))
+		default:
+			buf.WriteString(`\u` + leftPad(strconv.FormatInt(int64(r), 16), 4))
+		}
+	}
+	return c.writeContent(MINEApplicationJSON, buf.Bytes())
+}
+
+// leftPad a -> 000a
+func leftPad(s string, n int) string {
+	for len(s) < n {
+		s = "0" + s
+	}
+	return s
+}
+
+// SecureJSON return JSON prefixed with Config.SecureJSONPrefix,
+// which prevents JSON hijacking by <script> tags
+func (c *Context) SecureJSON(obj interface{}) error {
+	body, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	prefix := DefaultSecureJSONPrefix
+	if c.engine != nil && c.engine.config.SecureJSONPrefix != "" {
+		prefix = c.engine.config.SecureJSONPrefix
+	}
+	return c.writeContent(MINEApplicationJSON, append([]byte(prefix), body...))
+}
+
+// jsonpCallbackRegexp callback, jQuery123_456, app.callbacks[0]
+var jsonpCallbackRegexp = regexp.MustCompile(`^[a-zA-Z_$][a-zA-Z0-9_$]*(\.[a-zA-Z_$][a-zA-Z0-9_$]*|\[[0-9]+\])*$`)
+
+// JSONP return obj wrapped in a call to callback, JSON if callback is empty.
+// Invalid callback names result in a 400 error.
+// c.JSONP(c.Query("callback"), data)
+func (c *Context) JSONP(callback string, obj interface{}) error {
+	if callback == "" {
+		return c.JSON(obj)
+	}
+	if len(callback) > 128 || !jsonpCallbackRegexp.MatchString(callback) {
+		return NewError(http.StatusBadRequest, "invalid JSONP callback")
+	}
+	body, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	buf := new(bytes.Buffer)
+	// the comment prevents the Rosetta Flash attack, typeof ignores missing callbacks
+	buf.WriteString("/**/ typeof " + callback + " === 'function' && " + callback + "(")
+	buf.Write(body)
+	buf.WriteString(");")
+	c.Writer.Header().Set(HeaderXContentTypeOptions, "nosniff")
+	return c.writeContent(MIMEApplicationJavaScript, buf.Bytes())
+}
+
+// XML return XML
+func (c *Context) XML(obj interface{}) error {
+	body, err := xml.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	return c.writeContent(ContentTypeXml+CharsetSuffix, body)
+}
+
+// YAML return YAML
+func (c *Context) YAML(obj interface{}) error {
+	body, err := marshalYAML(obj)
+	if err != nil {
+		return err
+	}
+	return c.writeContent(ContentTypeYaml+CharsetSuffix, body)
+}
+
+// NDJSON streams newline delimited JSON, one line per item returned by next.
+// The stream ends when next returns io.EOF or the request is cancelled,
+// every line is flushed to the client.
+//
+//	c.NDJSON(func() (interface{}, error) {
+//		if !rows.Next() {
+//			return nil, io.EOF
+//		}
+//		return scanRow(rows)
+//	})
+func (c *Context) NDJSON(next func() (interface{}, error)) error {
+	c.Writer.Header().Set(HeaderContentType, MIMEApplicationNDJSON)
+	flusher, _ := c.Writer.(http.Flusher)
+	done := c.Request.Context().Done()
+	for {
+		select {
+		case <-done:
+			return nil
+		default:
+		}
+		item, err := next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		line, err := json.Marshal(item)
+		if err != nil {
+			return err
+		}
+		if _, err = c.Writer.Write(append(line, '\n')); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
+
+// OK renders data with status 200 by content negotiation, see Negotiate
+func (c *Context) OK(data interface{}) error {
+	return c.Negotiate(http.StatusOK, data)
+}
+
+// Created renders data with status 201
+func (c *Context) Created(data interface{}) error {
+	return c.Negotiate(http.StatusCreated, data)
+}
+
+// PartialContent renders data with status 206
+func (c *Context) PartialContent(data interface{}) error {
+	return c.Negotiate(http.StatusPartialContent, data)
+}
+
+// NoContent sends status 204 without body
+func (c *Context) NoContent() error {
+	c.Status(http.StatusNoContent)
+	return nil
+}
+
+// BadRequest renders err as HTTPResponse with status 400
+func (c *Context) BadRequest(err interface{}) error {
+	return c.renderHTTPResponse(http.StatusBadRequest, err)
+}
+
+// Unauthorized renders err as HTTPResponse with status 401
+func (c *Context) Unauthorized(err interface{}) error {
+	return c.renderHTTPResponse(http.StatusUnauthorized, err)
+}
+
+// Forbidden renders err as HTTPResponse with status 403
+func (c *Context) Forbidden(err interface{}) error {
+	return c.renderHTTPResponse(http.StatusForbidden, err)
+}
+
+// NotFound renders err as HTTPResponse with status 404
+func (c *Context) NotFound(err interface{}) error {
+	return c.renderHTTPResponse(http.StatusNotFound, err)
+}
+
+// InternalServerError renders err as HTTPResponse with status 500
+func (c *Context) InternalServerError(err interface{}) error {
+	return c.renderHTTPResponse(http.StatusInternalServerError, err)
+}
+
+func (c *Context) renderHTTPResponse(code int, err interface{}) error {
+	return c.Negotiate(code, NewHTTPResponse(code, err))
+}
+
+// renderShim runs a Context render helper on a plain http.ResponseWriter,
+// for http.Handler used with AdapterHandler
+func renderShim(w http.ResponseWriter, r *http.Request, render func(c *Context) error) {
+	c := NewContext(w, r)
+	if err := render(c); err != nil {
+		code := http.StatusInternalServerError
+		if e, ok := err.(*Error); ok {
+			code = e.Code
+		}
+		http.Error(c.Writer, err.Error(), code)
+	}
+	c.response.WriteHeaderNow()
+}
+
+// RenderBadRequest is the http.ResponseWriter version of Context.BadRequest
 func RenderBadRequest(w http.ResponseWriter, r *http.Request, err interface{}) {
-	resp := NewHTTPResponse(http.StatusBadRequest, err)
-	Render(w, r, resp.Code, resp)
+	renderShim(w, r, func(c *Context) error {
+		return c.BadRequest(err)
+	})
 }
 
+// RenderCreated is the http.ResponseWriter version of Context.Created
 func RenderCreated(w http.ResponseWriter, r *http.Request, err interface{}) {
-	Render(w, r, http.StatusCreated, err)
+	renderShim(w, r, func(c *Context) error {
+		return c.Created(err)
+	})
 }
 
+// RenderForbidden is the http.ResponseWriter version of Context.Forbidden
 func RenderForbidden(w http.ResponseWriter, r *http.Request, err interface{}) {
-	resp := NewHTTPResponse(http.StatusForbidden, err)
-	Render(w, r, resp.Code, resp)
+	renderShim(w, r, func(c *Context) error {
+		return c.Forbidden(err)
+	})
 }
 
+// RenderInternalServerError is the http.ResponseWriter version of Context.InternalServerError
 func RenderInternalServerError(w http.ResponseWriter, r *http.Request, err interface{}) {
-	resp := NewHTTPResponse(http.StatusInternalServerError, err)
-	Render(w, r, resp.Code, resp)
+	renderShim(w, r, func(c *Context) error {
+		return c.InternalServerError(err)
+	})
 }
 
 func RenderNoContent(w http.ResponseWriter) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// RenderNotFound is the http.ResponseWriter version of Context.NotFound
 func RenderNotFound(w http.ResponseWriter, r *http.Request, err interface{}) {
-	resp := NewHTTPResponse(http.StatusNotFound, err)
-	Render(w, r, resp.Code, resp)
+	renderShim(w, r, func(c *Context) error {
+		return c.NotFound(err)
+	})
 }
 
+// RenderUnauthorized is the http.ResponseWriter version of Context.Unauthorized
 func RenderUnauthorized(w http.ResponseWriter, r *http.Request, err interface{}) {
-	resp := NewHTTPResponse(http.StatusUnauthorized, err)
-	Render(w, r, resp.Code, resp)
+	renderShim(w, r, func(c *Context) error {
+		return c.Unauthorized(err)
+	})
 }
 
+// RenderOK is the http.ResponseWriter version of Context.OK
 func RenderOK(w http.ResponseWriter, r *http.Request, err interface{}) {
-	Render(w, r, http.StatusOK, err)
+	renderShim(w, r, func(c *Context) error {
+		return c.OK(err)
+	})
 }
 
+// RenderPartialContent is the http.ResponseWriter version of Context.PartialContent
 func RenderPartialContent(w http.ResponseWriter, r *http.Request, err interface{}) {
-	Render(w, r, http.StatusPartialContent, err)
+	renderShim(w, r, func(c *Context) error {
+		return c.PartialContent(err)
+	})
 }
 
+// Render is the http.ResponseWriter version of Context.Negotiate
 func Render(w http.ResponseWriter, r *http.Request, code int, d interface{}) {
-	switch r.Header.Get(HeaderAccept) {
-	case ContentTypeXml:
-		RenderXML(w, code, d)
-	default:
-		RenderJSON(w, code, d)
-	}
+	renderShim(w, r, func(c *Context) error {
+		return c.Negotiate(code, d)
+	})
 }
 
 func RenderJSON(w http.ResponseWriter, code int, d interface{}) {
@@ -85,14 +323,14 @@ func RenderJSON(w http.ResponseWriter, code int, d interface{}) {
 }
 
 func RenderXML(w http.ResponseWriter, code int, d interface{}) {
-	buf := new(bytes.Buffer)
-	if err := xml.NewEncoder(w).Encode(d); err != nil {
+	b, err := xml.Marshal(d)
+	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 	w.Header().Set(HeaderContentType, ContentTypeXml+CharsetSuffix)
 	w.WriteHeader(code)
-	_, _ = w.Write(buf.Bytes())
+	_, _ = w.Write(b)
 }
 
 func RenderRaw(w http.ResponseWriter, code int, d []byte) {
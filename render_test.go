@@ -0,0 +1,226 @@
+package seng
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRenderShims(t *testing.T) {
+	tests := []struct {
+		name     string
+		render   func(w http.ResponseWriter, r *http.Request)
+		wantCode int
+	}{
+		{"ok", func(w http.ResponseWriter, r *http.Request) { RenderOK(w, r, Map{"a": 1}) }, http.StatusOK},
+		{"created", func(w http.ResponseWriter, r *http.Request) { RenderCreated(w, r, Map{"a": 1}) }, http.StatusCreated},
+		{"bad request", func(w http.ResponseWriter, r *http.Request) { RenderBadRequest(w, r, errors.New("bad")) }, http.StatusBadRequest},
+		{"unauthorized", func(w http.ResponseWriter, r *http.Request) { RenderUnauthorized(w, r, "who") }, http.StatusUnauthorized},
+		{"forbidden", func(w http.ResponseWriter, r *http.Request) { RenderForbidden(w, r, "no") }, http.StatusForbidden},
+		{"not found", func(w http.ResponseWriter, r *http.Request) { RenderNotFound(w, r, "gone") }, http.StatusNotFound},
+		{"internal", func(w http.ResponseWriter, r *http.Request) { RenderInternalServerError(w, r, "oops") }, http.StatusInternalServerError},
+		{"no content", func(w http.ResponseWriter, r *http.Request) { RenderNoContent(w) }, http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set(HeaderAccept, ContentTypeJson)
+			tt.render(w, r)
+			if w.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantCode)
+			}
+			if tt.wantCode < 400 || tt.wantCode == http.StatusNoContent {
+				return
+			}
+			var resp HTTPResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("body %q: %v", w.Body, err)
+			}
+			if resp.Code != tt.wantCode || resp.Status != http.StatusText(tt.wantCode) || resp.Errors == nil {
+				t.Errorf("response = %+v", resp)
+			}
+		})
+	}
+}
+
+func TestRenderShimNotAcceptable(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(HeaderAccept, "image/png")
+	RenderOK(w, r, Map{"a": 1})
+	if w.Code != http.StatusNotAcceptable {
+		t.Errorf("status = %d, want 406", w.Code)
+	}
+}
+
+func TestRenderXMLHeaders(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(HeaderAccept, ContentTypeXml)
+	RenderNotFound(w, r, "gone")
+	if ct := w.Header().Get(HeaderContentType); ct != ContentTypeXml+CharsetSuffix {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if vary := w.Header().Get(HeaderVary); vary != HeaderAccept {
+		t.Errorf("Vary = %q", vary)
+	}
+	var resp HTTPResponse
+	if err := xml.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Code != http.StatusNotFound {
+		t.Errorf("body %q: %v", w.Body, err)
+	}
+}
+
+func TestContextErrorHelpers(t *testing.T) {
+	e := New()
+	e.GET("/", func(c *Context) error {
+		return c.Forbidden(errors.New("denied"))
+	})
+	w := serve(t, e, http.MethodGet, "/", "", HeaderAccept, ContentTypeJson)
+	if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), `"denied"`) {
+		t.Errorf("status %d, body %q", w.Code, w.Body)
+	}
+}
+
+type rendered struct {
+	XMLName xml.Name `json:"-" xml:"item"`
+	Name    string   `json:"name" xml:"name"`
+}
+
+func TestJSON(t *testing.T) {
+	item := rendered{Name: "café<>"}
+	tests := []struct {
+		name    string
+		release bool
+		obj     interface{}
+		want    string
+	}{
+		{"debug", false, item, "{\n    \"name\": \"café\\u003c\\u003e\"\n}\n"},
+		{"release", true, item, "{\"name\":\"café\\u003c\\u003e\"}\n"},
+		{"release slice", true, []int{1, 2}, "[1,2]\n"},
+	}
+	for _, tt := range tests {
+		e := New()
+		if tt.release {
+			e.SetReleaseMode()
+		}
+		e.GET("/", func(c *Context) error { return c.JSON(tt.obj) })
+		w := serve(t, e, http.MethodGet, "/", "")
+		if got := w.Body.String(); got != tt.want {
+			t.Errorf("%s: body = %q, want %q", tt.name, got, tt.want)
+		}
+		if got := w.Header().Get(HeaderContentType); got != MINEApplicationJSON {
+			t.Errorf("%s: Content-Type = %q", tt.name, got)
+		}
+	}
+
+	e := New()
+	e.GET("/", func(c *Context) error { return c.JSON(make(chan int)) })
+	if w := serve(t, e, http.MethodGet, "/", ""); w.Code != http.StatusInternalServerError || w.Header().Get(HeaderContentType) == MINEApplicationJSON {
+		t.Errorf("unsupported value: %d %q", w.Code, w.Header().Get(HeaderContentType))
+	}
+}
+
+func TestRenderers(t *testing.T) {
+	item := rendered{Name: "café<>"}
+	tests := []struct {
+		name        string
+		config      Config
+		render      func(c *Context) error
+		wantType    string
+		wantBody    string
+		wantCode    int
+		wantNosniff bool
+	}{
+		{"indented json", Config{}, func(c *Context) error { return c.IndentedJSON(Map{"a": 1}) },
+			MINEApplicationJSON, "{\n    \"a\": 1\n}", http.StatusOK, false},
+		{"ascii json", Config{}, func(c *Context) error { return c.AsciiJSON(Map{"a": "é😀"}) },
+			MINEApplicationJSON, `{"a":"\u00e9\ud83d\ude00"}`, http.StatusOK, false},
+		{"secure json", Config{}, func(c *Context) error { return c.SecureJSON([]int{1}) },
+			MINEApplicationJSON, "while(1);[1]", http.StatusOK, false},
+		{"secure json prefix", Config{SecureJSONPrefix: ")]}',\n"}, func(c *Context) error { return c.SecureJSON([]int{1}) },
+			MINEApplicationJSON, ")]}',\n[1]", http.StatusOK, false},
+		{"jsonp", Config{}, func(c *Context) error { return c.JSONP("app.cb[0]", []int{1}) },
+			MIMEApplicationJavaScript, "/**/ typeof app.cb[0] === 'function' && app.cb[0]([1]);", http.StatusOK, true},
+		{"jsonp without callback", Config{}, func(c *Context) error { return c.JSONP("", []int{1}) },
+			MINEApplicationJSON, "[\n    1\n]\n", http.StatusOK, false},
+		{"jsonp invalid callback", Config{}, func(c *Context) error { return c.JSONP("alert(1)//", []int{1}) },
+			"", "", http.StatusBadRequest, false},
+		{"xml", Config{}, func(c *Context) error { return c.XML(item) },
+			ContentTypeXml + CharsetSuffix, "<item><name>café&lt;&gt;</name></item>", http.StatusOK, false},
+		{"yaml", Config{}, func(c *Context) error { return c.YAML(item) },
+			ContentTypeYaml + CharsetSuffix, "name: café<>\n", http.StatusOK, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New(tt.config)
+			e.GET("/", tt.render)
+			w := serve(t, e, http.MethodGet, "/", "")
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantCode)
+			}
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+			if got := w.Header().Get(HeaderContentType); got != tt.wantType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantType)
+			}
+			if got := w.Body.String(); got != tt.wantBody {
+				t.Errorf("body = %q, want %q", got, tt.wantBody)
+			}
+			if got := w.Header().Get(HeaderXContentTypeOptions) == "nosniff"; got != tt.wantNosniff {
+				t.Errorf("nosniff = %t, want %t", got, tt.wantNosniff)
+			}
+		})
+	}
+}
+
+func TestRenderNDJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		items    []interface{}
+		err      error
+		wantBody string
+		wantCode int
+	}{
+		{"empty", nil, nil, "", http.StatusOK},
+		{"lines", []interface{}{Map{"a": 1}, "b", 3}, nil, "{\"a\":1}\n\"b\"\n3\n", http.StatusOK},
+		{"error first", nil, errors.New("boom"), "", http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New()
+			e.GET("/", func(c *Context) error {
+				i := 0
+				return c.NDJSON(func() (interface{}, error) {
+					if tt.err != nil {
+						return nil, tt.err
+					}
+					if i == len(tt.items) {
+						return nil, io.EOF
+					}
+					i++
+					return tt.items[i-1], nil
+				})
+			})
+			w := serve(t, e, http.MethodGet, "/", "")
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantCode)
+			}
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+			if got := w.Header().Get(HeaderContentType); got != MIMEApplicationNDJSON {
+				t.Errorf("Content-Type = %q", got)
+			}
+			if got := w.Body.String(); got != tt.wantBody {
+				t.Errorf("body = %q, want %q", got, tt.wantBody)
+			}
+		})
+	}
+}
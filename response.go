@@ -0,0 +1,116 @@
+package seng
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// ResponseWriter is the http.ResponseWriter of Context.
+// The status code is only sent with the first Write, Flush or WriteHeaderNow,
+// so headers can still be set after Context.Status.
+type ResponseWriter interface {
+	http.ResponseWriter
+	http.Flusher
+	http.Hijacker
+	// Status returns the status code of the response
+	Status() int
+	// Size returns the number of body bytes written
+	Size() int
+	// Written reports whether the status code and headers have been sent
+	Written() bool
+	// WriteHeaderNow sends the status code and headers
+	WriteHeaderNow()
+	// Unwrap returns the underlying http.ResponseWriter
+	Unwrap() http.ResponseWriter
+}
+
+// responseWriter implements ResponseWriter
+type responseWriter struct {
+	http.ResponseWriter
+	status   int
+	size     int
+	written  bool
+	hijacked bool
+	// see Context.BeforeWrite
+	beforeWrite []func()
+}
+
+var _ ResponseWriter = (*responseWriter)(nil)
+
+func (w *responseWriter) reset(writer http.ResponseWriter) {
+	w.ResponseWriter = writer
+	w.status = http.StatusOK
+	w.size = 0
+	w.written = false
+	w.hijacked = false
+	w.beforeWrite = w.beforeWrite[:0]
+}
+
+// WriteHeader records the status code, the last call before the first Write wins
+func (w *responseWriter) WriteHeader(code int) {
+	if code > 0 && !w.written {
+		w.status = code
+	}
+}
+
+// WriteHeaderNow implements ResponseWriter
+func (w *responseWriter) WriteHeaderNow() {
+	if !w.written && !w.hijacked {
+		w.written = true
+		for i := len(w.beforeWrite) - 1; i >= 0; i-- {
+			w.beforeWrite[i]()
+		}
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+}
+
+// Write implements http.ResponseWriter
+func (w *responseWriter) Write(data []byte) (n int, err error) {
+	w.WriteHeaderNow()
+	n, err = w.ResponseWriter.Write(data)
+	w.size += n
+	return
+}
+
+// Status implements ResponseWriter
+func (w *responseWriter) Status() int {
+	return w.status
+}
+
+// Size implements ResponseWriter
+func (w *responseWriter) Size() int {
+	return w.size
+}
+
+// Written implements ResponseWriter
+func (w *responseWriter) Written() bool {
+	return w.written
+}
+
+// Flush implements http.Flusher
+func (w *responseWriter) Flush() {
+	w.WriteHeaderNow()
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("seng: the ResponseWriter does not implement http.Hijacker")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err == nil {
+		w.hijacked = true
+	}
+	return conn, rw, err
+}
+
+// Unwrap implements ResponseWriter, used by http.ResponseController
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
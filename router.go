@@ -1,6 +1,9 @@
 package seng
 
 import (
+	"fmt"
+	"net/url"
+	"reflect"
 	"strings"
 )
 
@@ -13,6 +16,71 @@ type Router struct {
 	// Amount of registered handlers
 	handlerCount uint32
 	handlers     map[string]Handler
+	// registered routes in order
+	routes []*Route
+	// named routes
+	names map[string]*Route
+}
+
+// Route describes a registered route
+type Route struct {
+	Method string
+	Path   string
+	// Name is set by Named
+	Name string
+	// Request and Response are set for typed handlers, see Typed
+	Request  reflect.Type
+	Response reflect.Type
+	// reference to router
+	router *Router
+}
+
+// Named names the route for Engine.URL and Context.RedirectRoute
+// e.GET("/pets/:id", handler).Named("pet")
+func (route *Route) Named(name string) *Route {
+	// routes skipped by GETOnly are nil
+	if route == nil {
+		return nil
+	}
+	if _, ok := route.router.names[name]; ok {
+		panic(fmt.Sprintf("seng: route name %q is already used", name))
+	}
+	route.Name = name
+	route.router.names[name] = route
+	return route
+}
+
+// URL builds the path of the route from params, as key value pairs
+// route.URL("id", 1) -> /pets/1
+func (route *Route) URL(params ...interface{}) (string, error) {
+	if len(params)%2 != 0 {
+		return "", fmt.Errorf("seng: route %s: odd number of params", route.Path)
+	}
+	values := make(map[string]string, len(params)/2)
+	for i := 0; i < len(params); i += 2 {
+		values[fmt.Sprint(params[i])] = fmt.Sprint(params[i+1])
+	}
+	parts := strings.Split(route.Path, "/")
+	for i, part := range parts {
+		if part == "" || (part[0] != ':' && part[0] != '*') {
+			continue
+		}
+		value, ok := values[part[1:]]
+		if !ok {
+			return "", fmt.Errorf("seng: route %s: missing param %s", route.Path, part[1:])
+		}
+		if part[0] == '*' {
+			// wildcards keep their slashes
+			segments := strings.Split(value, "/")
+			for j, segment := range segments {
+				segments[j] = url.PathEscape(segment)
+			}
+			parts[i] = strings.Join(segments, "/")
+			continue
+		}
+		parts[i] = url.PathEscape(value)
+	}
+	return strings.Join(parts, "/"), nil
 }
 
 // NewRouter create a new Router instance
@@ -20,6 +88,7 @@ func NewRouter() *Router {
 	return &Router{
 		roots:    make(map[string]*node),
 		handlers: make(map[string]Handler),
+		names:    make(map[string]*Route),
 	}
 }
 
@@ -48,7 +117,7 @@ func combineRouteKey(method string, pattern string) string {
 }
 
 // addRoute add route to router
-func (r *Router) addRoute(method string, pattern string, handler Handler) {
+func (r *Router) addRoute(method string, pattern string, handler Handler) *Route {
 	parts := parsePattern(pattern)
 	key := combineRouteKey(method, pattern)
 	_, ok := r.roots[method]
@@ -58,6 +127,9 @@ func (r *Router) addRoute(method string, pattern string, handler Handler) {
 	// insert node
 	r.roots[method].insert(pattern, parts, 0)
 	r.handlers[key] = handler
+	route := &Route{Method: method, Path: pattern, router: r}
+	r.routes = append(r.routes, route)
+	return route
 }
 
 // getRoute match route
@@ -101,10 +173,11 @@ func (r *Router) handle(c *Context) error {
 		if handler, ok := r.handlers[key]; ok {
 			c.handlers = append(c.handlers, handler)
 		} else {
-			c.handlers = append(c.handlers, c.engine.config.NotFoundErrorHandler)
+			c.handlers = append(c.handlers, c.engine.notFoundHandler(c.Path))
 		}
 	} else {
-		return c.engine.config.NotFoundErrorHandler(c)
+		// middlewares run before the not found handler as for other routes
+		c.handlers = append(c.handlers, c.engine.notFoundHandler(c.Path))
 	}
 	// handle
 	return c.Next()
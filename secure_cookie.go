@@ -0,0 +1,252 @@
+package seng
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNoCookieKeys is returned by signed and encrypted cookies without Config.CookieKeys
+	ErrNoCookieKeys = errors.New("seng: Config.CookieKeys is not set")
+	// ErrInvalidCookie is returned for cookies which were modified or not
+	// signed or encrypted by any of Config.CookieKeys
+	ErrInvalidCookie = errors.New("seng: invalid cookie")
+	// ErrExpiredCookie is returned for signed and encrypted cookies which
+	// expired or are older than Config.CookieMaxAge
+	ErrExpiredCookie = errors.New("seng: expired cookie")
+)
+
+// MinCookieKeyLength minimum length of Config.CookieKeys
+const MinCookieKeyLength = 16
+
+// cookieKey keys derived from a secret of Config.CookieKeys
+type cookieKey struct {
+	sign []byte
+	aead cipher.AEAD
+}
+
+// newCookieKey derives the signing and the AES-256 encryption keys of secret
+func newCookieKey(secret []byte) cookieKey {
+	if len(secret) < MinCookieKeyLength {
+		panic("seng: cookie keys must be at least 16 bytes")
+	}
+	block, err := aes.NewCipher(deriveKey(secret, "seng encrypted cookie"))
+	if err != nil {
+		panic(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		panic(err)
+	}
+	return cookieKey{sign: deriveKey(secret, "seng signed cookie"), aead: aead}
+}
+
+// deriveKey derives a 32 bytes key of secret for purpose
+func deriveKey(secret []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
+
+// initCookieKeys derives the keys of Config.CookieKeys
+func (e *Engine) initCookieKeys() {
+	e.cookieKeys = make([]cookieKey, 0, len(e.config.CookieKeys))
+	for _, secret := range e.config.CookieKeys {
+		e.cookieKeys = append(e.cookieKeys, newCookieKey(secret))
+	}
+}
+
+// cookieTimesSize size of the issued-at and expiry times preceding the value
+const cookieTimesSize = 16
+
+// cookieExpiry returns the expiry of cookie, zero for session cookies
+func cookieExpiry(cookie *http.Cookie) time.Time {
+	if cookie.MaxAge > 0 {
+		return time.Now().Add(time.Duration(cookie.MaxAge) * time.Second)
+	}
+	if cookie.MaxAge < 0 {
+		return time.Now()
+	}
+	return cookie.Expires
+}
+
+// cookiePayload returns value preceded by the Unix times it is issued at and
+// expires at, zero if it does not expire
+func cookiePayload(value string, expires time.Time) []byte {
+	payload := make([]byte, cookieTimesSize, cookieTimesSize+len(value))
+	binary.BigEndian.PutUint64(payload, uint64(time.Now().Unix()))
+	if !expires.IsZero() {
+		binary.BigEndian.PutUint64(payload[8:], uint64(expires.Unix()))
+	}
+	return append(payload, value...)
+}
+
+// cookieValue returns the value of the authenticated payload, ErrExpiredCookie
+// if it expired or is older than Config.CookieMaxAge
+func (e *Engine) cookieValue(payload []byte) (string, error) {
+	if len(payload) < cookieTimesSize {
+		return "", ErrInvalidCookie
+	}
+	now := time.Now().Unix()
+	issued := int64(binary.BigEndian.Uint64(payload))
+	expires := int64(binary.BigEndian.Uint64(payload[8:]))
+	if expires != 0 && now >= expires {
+		return "", ErrExpiredCookie
+	}
+	if maxAge := e.config.CookieMaxAge; maxAge > 0 && now-issued >= int64(maxAge/time.Second) {
+		return "", ErrExpiredCookie
+	}
+	return string(payload[cookieTimesSize:]), nil
+}
+
+// cookieMAC the signature of the cookie name with value
+func cookieMAC(key []byte, name, value string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(name))
+	mac.Write([]byte{'='})
+	mac.Write([]byte(value))
+	return mac.Sum(nil)
+}
+
+// SignCookie returns value signed with the newest cookie key for the cookie
+// name, the value stays readable by clients. The signature covers the time
+// it is issued at and expires, a zero expires never expires.
+func (e *Engine) SignCookie(name, value string, expires time.Time) (string, error) {
+	if len(e.cookieKeys) == 0 {
+		return "", ErrNoCookieKeys
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(cookiePayload(value, expires))
+	mac := cookieMAC(e.cookieKeys[0].sign, name, encoded)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(mac), nil
+}
+
+// VerifyCookie returns the value of a cookie signed by SignCookie with any of
+// the cookie keys, ErrExpiredCookie if it expired
+func (e *Engine) VerifyCookie(name, signed string) (string, error) {
+	if len(e.cookieKeys) == 0 {
+		return "", ErrNoCookieKeys
+	}
+	i := strings.LastIndexByte(signed, '.')
+	if i < 0 {
+		return "", ErrInvalidCookie
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signed[i+1:])
+	if err != nil {
+		return "", ErrInvalidCookie
+	}
+	encoded := signed[:i]
+	for _, key := range e.cookieKeys {
+		if hmac.Equal(mac, cookieMAC(key.sign, name, encoded)) {
+			payload, err := base64.RawURLEncoding.DecodeString(encoded)
+			if err != nil {
+				return "", ErrInvalidCookie
+			}
+			return e.cookieValue(payload)
+		}
+	}
+	return "", ErrInvalidCookie
+}
+
+// EncryptCookie returns value encrypted and authenticated with AES-GCM with
+// the newest cookie key for the cookie name, with the time it is issued at
+// and expires, a zero expires never expires
+func (e *Engine) EncryptCookie(name, value string, expires time.Time) (string, error) {
+	if len(e.cookieKeys) == 0 {
+		return "", ErrNoCookieKeys
+	}
+	aead := e.cookieKeys[0].aead
+	payload := cookiePayload(value, expires)
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(payload)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, payload, []byte(name))
+	return base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptCookie returns the value of a cookie encrypted by EncryptCookie with
+// any of the cookie keys, ErrExpiredCookie if it expired
+func (e *Engine) DecryptCookie(name, encrypted string) (string, error) {
+	if len(e.cookieKeys) == 0 {
+		return "", ErrNoCookieKeys
+	}
+	sealed, err := base64.RawURLEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", ErrInvalidCookie
+	}
+	for _, key := range e.cookieKeys {
+		nonceSize := key.aead.NonceSize()
+		if len(sealed) < nonceSize {
+			return "", ErrInvalidCookie
+		}
+		payload, err := key.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], []byte(name))
+		if err == nil {
+			return e.cookieValue(payload)
+		}
+	}
+	return "", ErrInvalidCookie
+}
+
+// SetSignedCookie sets cookie with its value signed until it expires, see
+// GetSignedCookie. It returns ErrInsecureCookie like CookieBuilder.Set.
+func (c *Context) SetSignedCookie(cookie *http.Cookie) error {
+	if err := validateCookie(cookie); err != nil {
+		return err
+	}
+	value, err := c.engine.SignCookie(cookie.Name, cookie.Value, cookieExpiry(cookie))
+	if err != nil {
+		return err
+	}
+	signed := *cookie
+	signed.Value = value
+	c.SetCookie(&signed)
+	return nil
+}
+
+// GetSignedCookie returns the value of the signed cookie name,
+// http.ErrNoCookie if it is missing, ErrInvalidCookie if it was modified or
+// ErrExpiredCookie if it expired
+func (c *Context) GetSignedCookie(name string) (string, error) {
+	cookie, err := c.GetCookie(name)
+	if err != nil {
+		return "", err
+	}
+	return c.engine.VerifyCookie(name, cookie.Value)
+}
+
+// SetEncryptedCookie sets cookie with its value encrypted until it expires,
+// see GetEncryptedCookie. It returns ErrInsecureCookie like CookieBuilder.Set.
+func (c *Context) SetEncryptedCookie(cookie *http.Cookie) error {
+	if err := validateCookie(cookie); err != nil {
+		return err
+	}
+	value, err := c.engine.EncryptCookie(cookie.Name, cookie.Value, cookieExpiry(cookie))
+	if err != nil {
+		return err
+	}
+	encrypted := *cookie
+	encrypted.Value = value
+	c.SetCookie(&encrypted)
+	return nil
+}
+
+// GetEncryptedCookie returns the value of the encrypted cookie name,
+// http.ErrNoCookie if it is missing, ErrInvalidCookie if it was modified or
+// ErrExpiredCookie if it expired
+func (c *Context) GetEncryptedCookie(name string) (string, error) {
+	cookie, err := c.GetCookie(name)
+	if err != nil {
+		return "", err
+	}
+	return c.engine.DecryptCookie(name, cookie.Value)
+}
@@ -0,0 +1,172 @@
+package seng
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	testCookieKey  = []byte("0123456789abcdef0123456789abcdef")
+	otherCookieKey = []byte("fedcba9876543210fedcba9876543210")
+)
+
+// signCookieAt signs value for name as if it was issued at issued
+func signCookieAt(e *Engine, name, value string, issued, expires time.Time) string {
+	payload := make([]byte, cookieTimesSize, cookieTimesSize+len(value))
+	binary.BigEndian.PutUint64(payload, uint64(issued.Unix()))
+	if !expires.IsZero() {
+		binary.BigEndian.PutUint64(payload[8:], uint64(expires.Unix()))
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(append(payload, value...))
+	mac := cookieMAC(e.cookieKeys[0].sign, name, encoded)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(mac)
+}
+
+func TestSecureCookies(t *testing.T) {
+	kinds := []struct {
+		name   string
+		seal   func(e *Engine, name, value string, expires time.Time) (string, error)
+		unseal func(e *Engine, name, sealed string) (string, error)
+	}{
+		{"signed", (*Engine).SignCookie, (*Engine).VerifyCookie},
+		{"encrypted", (*Engine).EncryptCookie, (*Engine).DecryptCookie},
+	}
+	tests := []struct {
+		name    string
+		keys    [][]byte
+		maxAge  time.Duration
+		expires time.Time
+		// readKeys keys of the engine reading the cookie, keys by default
+		readKeys [][]byte
+		modify   func(sealed string) string
+		readName string
+		wantErr  error
+	}{
+		{name: "session", keys: [][]byte{testCookieKey}},
+		{name: "not expired", keys: [][]byte{testCookieKey}, expires: time.Now().Add(time.Hour)},
+		{name: "expired", keys: [][]byte{testCookieKey}, expires: time.Now().Add(-time.Second), wantErr: ErrExpiredCookie},
+		{name: "within max age", keys: [][]byte{testCookieKey}, maxAge: time.Hour},
+		{name: "rotated key", keys: [][]byte{otherCookieKey}, readKeys: [][]byte{testCookieKey, otherCookieKey}},
+		{name: "unknown key", keys: [][]byte{otherCookieKey}, readKeys: [][]byte{testCookieKey}, wantErr: ErrInvalidCookie},
+		{name: "other name", keys: [][]byte{testCookieKey}, readName: "other", wantErr: ErrInvalidCookie},
+		{name: "modified", keys: [][]byte{testCookieKey}, modify: func(s string) string { b := []byte(s); b[len(b)/2] ^= 1; return string(b) }, wantErr: ErrInvalidCookie},
+		{name: "truncated", keys: [][]byte{testCookieKey}, modify: func(s string) string { return s[:4] }, wantErr: ErrInvalidCookie},
+		{name: "no keys", wantErr: ErrNoCookieKeys},
+	}
+	for _, kind := range kinds {
+		for _, tt := range tests {
+			t.Run(kind.name+" "+tt.name, func(t *testing.T) {
+				e := New(Config{CookieKeys: tt.keys, CookieMaxAge: tt.maxAge})
+				// a space is never part of the sealed base64 value
+				sealed, err := kind.seal(e, "uid", "user 42", tt.expires)
+				if tt.wantErr == ErrNoCookieKeys {
+					if err != ErrNoCookieKeys {
+						t.Errorf("seal = %v, want ErrNoCookieKeys", err)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if strings.Contains(sealed, " ") {
+					t.Errorf("encrypted value %q contains the value", sealed)
+				}
+				if tt.modify != nil {
+					sealed = tt.modify(sealed)
+				}
+				reader := e
+				if tt.readKeys != nil {
+					reader = New(Config{CookieKeys: tt.readKeys})
+				}
+				name := "uid"
+				if tt.readName != "" {
+					name = tt.readName
+				}
+				value, err := kind.unseal(reader, name, sealed)
+				if err != tt.wantErr {
+					t.Fatalf("unseal = %q, %v, want %v", value, err, tt.wantErr)
+				}
+				if err == nil && value != "user 42" {
+					t.Errorf("unseal = %q, want %q", value, "user 42")
+				}
+			})
+		}
+	}
+}
+
+func TestCookieMaxAge(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name    string
+		maxAge  time.Duration
+		issued  time.Time
+		expires time.Time
+		wantErr error
+	}{
+		{"unlimited", 0, now.Add(-24 * time.Hour), time.Time{}, nil},
+		{"within max age", time.Hour, now.Add(-time.Minute), time.Time{}, nil},
+		{"older than max age", time.Hour, now.Add(-2 * time.Hour), time.Time{}, ErrExpiredCookie},
+		{"older than max age but not expired", time.Hour, now.Add(-2 * time.Hour), now.Add(time.Hour), ErrExpiredCookie},
+		{"expired within max age", time.Hour, now.Add(-time.Minute), now.Add(-time.Second), ErrExpiredCookie},
+	}
+	for _, tt := range tests {
+		e := New(Config{CookieKeys: [][]byte{testCookieKey}, CookieMaxAge: tt.maxAge})
+		signed := signCookieAt(e, "uid", "42", tt.issued, tt.expires)
+		if _, err := e.VerifyCookie("uid", signed); err != tt.wantErr {
+			t.Errorf("%s: VerifyCookie = %v, want %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestContextSecureCookies(t *testing.T) {
+	tests := []struct {
+		name    string
+		cookie  http.Cookie
+		wantErr error
+	}{
+		{"session", http.Cookie{Name: "uid", Value: "42"}, nil},
+		{"max age", http.Cookie{Name: "uid", Value: "42", MaxAge: 60}, nil},
+		{"expires", http.Cookie{Name: "uid", Value: "42", Expires: time.Now().Add(time.Hour)}, nil},
+		{"expired", http.Cookie{Name: "uid", Value: "42", Expires: time.Now().Add(-time.Hour)}, ErrExpiredCookie},
+		{"deleted", http.Cookie{Name: "uid", Value: "42", MaxAge: -1}, ErrExpiredCookie},
+	}
+	kinds := []struct {
+		name string
+		set  func(c *Context, cookie *http.Cookie) error
+		get  func(c *Context, name string) (string, error)
+	}{
+		{"signed", (*Context).SetSignedCookie, (*Context).GetSignedCookie},
+		{"encrypted", (*Context).SetEncryptedCookie, (*Context).GetEncryptedCookie},
+	}
+	for _, kind := range kinds {
+		for _, tt := range tests {
+			t.Run(kind.name+" "+tt.name, func(t *testing.T) {
+				e := New(Config{CookieKeys: [][]byte{testCookieKey}})
+				cookie := tt.cookie
+				e.GET("/set", func(c *Context) error { return kind.set(c, &cookie) })
+				set := serve(t, e, http.MethodGet, "/set", "")
+				if cookie.Value != "42" {
+					t.Errorf("the cookie was modified: %+v", cookie)
+				}
+				header := set.Header().Get(HeaderSetCookie)
+				if header == "" || strings.Contains(header, "uid=42") {
+					t.Fatalf("Set-Cookie = %q", header)
+				}
+				var got string
+				var err error
+				e.GET("/read", func(c *Context) error {
+					got, err = kind.get(c, "uid")
+					return nil
+				})
+				serve(t, e, http.MethodGet, "/read", "", HeaderCookie, strings.SplitN(header, ";", 2)[0])
+				if err != tt.wantErr || (err == nil && got != "42") {
+					t.Errorf("get = %q, %v, want %v", got, err, tt.wantErr)
+				}
+			})
+		}
+	}
+}
@@ -1,7 +1,9 @@
 package seng
 
 import (
+	"fmt"
 	"html/template"
+	"io/fs"
 	"log"
 	"net/http"
 	"strings"
@@ -39,19 +41,60 @@ type Config struct {
 	ReadHeaderTimeout time.Duration `json:"read_header_timeout"`
 	// Default: false
 	GETOnly bool `json:"get_only"`
-	// print routes
+	// print routes, indent Context.JSON, parse changed templates and views
+	// again before rendering and disable the cache of Engine.AssetURL.
+	// Every render checks the template files, Engine.SetReleaseMode turns
+	// it off.
 	// Default: true
 	Debug bool `json:"debug"`
 	// Cookie
 	// http.SameSiteStrictMode http.SameSiteLaxMode http.SameSiteNoneMode
 	// http.SameSiteNoneMode must set secure to true
 	CookieSameSite http.SameSite `json:"cookie_same_site"`
+	// Cookie default attributes of cookies, see CookieConfig
+	Cookie CookieConfig `json:"cookie"`
+	// FlashStore persists the messages of Context.Flash
+	// Default: CookieFlashStore, which needs CookieKeys
+	FlashStore FlashStore `json:"-"`
+	// CookieKeys secrets of signed and encrypted cookies, at least 16 random
+	// bytes each. The first key signs and encrypts, the others only verify
+	// and decrypt cookies so that keys can be rotated.
+	CookieKeys [][]byte `json:"-"`
+	// CookieMaxAge maximum age of signed and encrypted cookies since they
+	// were set, in addition to their expiry
+	// Default: unlimited
+	CookieMaxAge time.Duration `json:"cookie_max_age"`
 	// Default: false
 	DisableKeepalive bool `json:"disable_keepalive"`
 	// ErrorHandler Default: DefaultErrorHandler
 	ErrorHandler ErrorHandler `json:"-"`
 	// NotFoundHandler Default: DefaultNotFoundErrorHandler
 	NotFoundErrorHandler Handler `json:"-"`
+	// SecureJSONPrefix prefix of Context.SecureJSON
+	// Default: "while(1);"
+	SecureJSONPrefix string `json:"secure_json_prefix"`
+	// RedirectAllowedHosts external hosts Context.Redirect may redirect to,
+	// "*.example.com" allows subdomains
+	// Default: none
+	RedirectAllowedHosts []string `json:"redirect_allowed_hosts"`
+	// Views renders templates for Context.Render, see HTMLViews
+	Views Views `json:"-"`
+	// ViewFuncs template functions bound to each request, replacing the
+	// template functions of the same name, see ViewFunc
+	// Default: url, csrf, t, asset, local, flush and flashes
+	ViewFuncs map[string]ViewFunc `json:"-"`
+	// Translator of Context.Translate and the t template function
+	Translator Translator `json:"-"`
+	// AssetsFS files of Engine.AssetURL and the asset template function,
+	// e.g. os.DirFS("public")
+	AssetsFS fs.FS `json:"-"`
+	// AssetsPrefix URL prefix of Engine.AssetURL
+	// Default: "/"
+	AssetsPrefix string `json:"assets_prefix"`
+	// SSEHeartbeat interval of the heartbeat comments of Context.Stream,
+	// negative disables heartbeats
+	// Default: 15s
+	SSEHeartbeat time.Duration `json:"sse_heartbeat"`
 	// seng version
 	SengVersion string      `json:"seng_version"`
 	Logger      *log.Logger `json:"logger"`
@@ -64,20 +107,24 @@ const (
 	DefaultWriteBufferSize = 4096
 	DefaultCookieSameSite  = http.SameSiteLaxMode
 	DefaultListenAddr      = ":8080"
+	// DefaultSecureJSONPrefix prefix of Context.SecureJSON
+	DefaultSecureJSONPrefix = "while(1);"
+	// DefaultSSEHeartbeat interval of the heartbeat comments of Context.Stream
+	DefaultSSEHeartbeat = 15 * time.Second
 )
 
 // DefaultErrorHandler default error handler.
+// Errors are resolved with Engine.ResolveError, so errors of MapError are
+// rendered with their status code.
 var DefaultErrorHandler = func(c *Context, err error) error {
-	code := http.StatusInternalServerError
-	if e, ok := err.(*Error); ok {
-		code = e.Code
-	}
-	return c.Status(code).Text(err.Error())
+	e := c.engine.ResolveError(err)
+	setErrorHeaders(c, e)
+	return c.Status(e.Code).Text("%s", e.Message)
 }
 
+// DefaultNotFoundErrorHandler returns a 404 error for the error handlers
 var DefaultNotFoundErrorHandler = func(c *Context) error {
-	code := http.StatusNotFound
-	return c.Status(code).Text("404 not found")
+	return NewError(http.StatusNotFound, "404 not found")
 }
 
 // defaultConfig default engine config
@@ -102,13 +149,27 @@ type Engine struct {
 	Logger *log.Logger
 	// Ctx pool
 	ctxPool sync.Pool
-	// Validator Pool
-	validatorPool sync.Pool
-	router        *Router
-	groups        []*RouterGroup
+	// struct tag validator
+	validator *Validator
+	// see MapError
+	errorMappers []ErrorMapper
+	router       *Router
+	groups       []*RouterGroup
 	// template
-	htmlTemplates *template.Template
+	templateMutex sync.RWMutex
+	htmlTemplates *templateSet
 	funcMap       template.FuncMap
+	// see LoadHTMLGlob, reloaded in debug mode
+	htmlSource  *templateSource
+	htmlVersion string
+	// error of LoadViews, returned when rendering views
+	viewsErr error
+	// see Config.CookieKeys
+	cookieKeys []cookieKey
+	// see SetViewData
+	viewData      Map
+	viewFuncs     map[string]ViewFunc
+	assetVersions sync.Map
 }
 
 // New create a new instance of Engine
@@ -119,10 +180,8 @@ func New(config ...Config) *Engine {
 		ctxPool: sync.Pool{New: func() interface{} {
 			return new(Context)
 		}},
-		validatorPool: sync.Pool{New: func() interface{} {
-			return new(Validator)
-		}},
-		config: Config{},
+		validator: new(Validator),
+		config:    Config{},
 	}
 
 	if len(config) > 0 {
@@ -142,14 +201,44 @@ func New(config ...Config) *Engine {
 	if engine.config.Debug == false {
 		engine.config.Debug = true
 	}
+	if engine.config.SecureJSONPrefix == "" {
+		engine.config.SecureJSONPrefix = DefaultSecureJSONPrefix
+	}
+	if engine.config.SSEHeartbeat == 0 {
+		engine.config.SSEHeartbeat = DefaultSSEHeartbeat
+	}
 	if engine.config.CookieSameSite == 0 {
 		engine.config.CookieSameSite = http.SameSiteLaxMode
 	}
+	if engine.config.Cookie.Path == "" {
+		engine.config.Cookie.Path = DefaultCookiePath
+	}
+	if engine.config.Cookie.SameSite == 0 {
+		engine.config.Cookie.SameSite = engine.config.CookieSameSite
+	}
+	if err := validateCookie(&http.Cookie{
+		Secure:      engine.config.Cookie.Secure,
+		SameSite:    engine.config.Cookie.SameSite,
+		Partitioned: engine.config.Cookie.Partitioned,
+	}); err != nil {
+		panic(err)
+	}
 	if engine.Logger == nil {
 		logger := log.Default()
 		engine.config.Logger = logger
 		engine.Logger = logger
 	}
+	if engine.config.FlashStore == nil {
+		engine.config.FlashStore = CookieFlashStore{}
+	}
+	if engine.config.AssetsPrefix == "" {
+		engine.config.AssetsPrefix = "/"
+	}
+	engine.initViewFuncs()
+	engine.initCookieKeys()
+	if err := engine.LoadViews(); err != nil {
+		engine.Logger.Printf("seng: failed to load views: %v", err)
+	}
 	engine.config.SengVersion = Version
 	// init Engine
 	engine.init()
@@ -197,7 +286,7 @@ func Default() *Engine {
 // ServeHTTP implements http.Handler
 func (e *Engine) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	var middleWares []Handler
-	// add middlewares
+	// add middlewares, also run for unknown routes
 	for _, group := range e.groups {
 		if strings.HasPrefix(req.URL.Path, group.prefix) {
 			middleWares = append(middleWares, group.middleWares...)
@@ -211,13 +300,10 @@ func (e *Engine) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	ctx.handlers = middleWares
 	// handle request
 	if err := e.router.handle(ctx); err != nil {
-		err := e.config.ErrorHandler(ctx, err)
-		if err != nil {
-			// release
-			e.ReleaseCtx(ctx)
-			return
-		}
+		e.handleError(ctx, err)
 	}
+	// send the status code of responses without body
+	ctx.response.WriteHeaderNow()
 	// release
 	e.ReleaseCtx(ctx)
 }
@@ -230,6 +316,7 @@ func (e *Engine) AcquireCtx() *Context {
 func (e *Engine) ReleaseCtx(ctx *Context) {
 	// clean
 	ctx.Writer = nil
+	ctx.response.reset(nil)
 	ctx.Request = nil
 	ctx.handlers = nil
 	// put to ctxPool
@@ -267,6 +354,35 @@ func (e *Engine) Run(address ...string) error {
 	return e.Listen(address...)
 }
 
+// Validator returns the validator used by Context.Validate
+func (e *Engine) Validator() *Validator {
+	return e.validator
+}
+
+// RegisterValidation register a custom validation rule, see Validator.RegisterValidation
+func (e *Engine) RegisterValidation(tag string, fn ValidationFunc, message ...string) error {
+	return e.validator.RegisterValidation(tag, fn, message...)
+}
+
+// Routes returns all registered routes in registration order
+func (e *Engine) Routes() []Route {
+	routes := make([]Route, 0, len(e.router.routes))
+	for _, route := range e.router.routes {
+		routes = append(routes, *route)
+	}
+	return routes
+}
+
+// URL builds the path of the named route from params, as key value pairs
+// e.URL("pet", "id", 1) -> /pets/1
+func (e *Engine) URL(name string, params ...interface{}) (string, error) {
+	route, ok := e.router.names[name]
+	if !ok {
+		return "", fmt.Errorf("seng: unknown route name %q", name)
+	}
+	return route.URL(params...)
+}
+
 // Config get engine config
 func (e *Engine) Config() Config {
 	return e.config
@@ -0,0 +1,212 @@
+package seng
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ServerSentEvent is an event of a text/event-stream response
+type ServerSentEvent struct {
+	// ID is sent back by the browser as Last-Event-ID when it reconnects
+	ID string
+	// Event name, "message" when empty
+	Event string
+	// Data strings and []byte are sent as is, other values as JSON
+	Data interface{}
+	// Retry reconnection time of the browser
+	Retry time.Duration
+}
+
+// encode writes the event in the text/event-stream format
+func (e ServerSentEvent) encode(w io.Writer) error {
+	buf := new(bytes.Buffer)
+	if e.ID != "" {
+		buf.WriteString("id: " + sseSanitize(e.ID) + "\n")
+	}
+	if e.Event != "" {
+		buf.WriteString("event: " + sseSanitize(e.Event) + "\n")
+	}
+	if e.Retry > 0 {
+		buf.WriteString("retry: " + strconv.FormatInt(int64(e.Retry/time.Millisecond), 10) + "\n")
+	}
+	var data string
+	switch d := e.Data.(type) {
+	case nil:
+	case string:
+		data = d
+	case []byte:
+		data = string(d)
+	default:
+		b, err := json.Marshal(d)
+		if err != nil {
+			return err
+		}
+		data = string(b)
+	}
+	// every line of data is a data field
+	for _, line := range strings.Split(strings.Replace(data, "\r\n", "\n", -1), "\n") {
+		buf.WriteString("data: " + line + "\n")
+	}
+	buf.WriteByte('\n')
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// sseSanitize removes line breaks which would end a field
+func sseSanitize(s string) string {
+	return strings.NewReplacer("\n", "", "\r", "").Replace(s)
+}
+
+// setSSEHeaders sets the text/event-stream headers once
+func (c *Context) setSSEHeaders() {
+	header := c.Writer.Header()
+	if header.Get(HeaderContentType) == MIMETextEventStream {
+		return
+	}
+	header.Set(HeaderContentType, MIMETextEventStream)
+	header.Set(HeaderCacheControl, "no-cache")
+	header.Set(HeaderConnection, "keep-alive")
+	// disable nginx buffering
+	header.Set(HeaderXAccelBuffering, "no")
+}
+
+// SSEvent sends a server-sent event with name and data and flushes it
+// c.SSEvent("progress", seng.Map{"percent": 50})
+func (c *Context) SSEvent(name string, data interface{}) error {
+	return c.SendEvent(ServerSentEvent{Event: name, Data: data})
+}
+
+// SendEvent sends a server-sent event with id and retry fields and flushes it
+func (c *Context) SendEvent(event ServerSentEvent) error {
+	c.setSSEHeaders()
+	if err := event.encode(c.Writer); err != nil {
+		return err
+	}
+	if flusher, ok := c.Writer.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
+
+// LastEventID returns the id of the last event received by a reconnecting
+// client, streams should resume after it
+func (c *Context) LastEventID() string {
+	return c.GetHeader(HeaderLastEventID)
+}
+
+// streamWriter buffers the output of a Stream step
+type streamWriter struct {
+	http.ResponseWriter
+	buf bytes.Buffer
+}
+
+// Write implements http.ResponseWriter
+func (w *streamWriter) Write(data []byte) (int, error) {
+	return w.buf.Write(data)
+}
+
+// WriteHeader status code is sent when the stream starts
+func (w *streamWriter) WriteHeader(int) {}
+
+// Stream sends a text/event-stream response, calling step until it returns
+// false or the client disconnects. The output of each step, including events
+// sent with SSEvent, is flushed after the step returns. A heartbeat comment is
+// sent every Config.SSEHeartbeat so that proxies keep the connection open while
+// step is waiting. Stream returns true if the client disconnected.
+//
+//	c.Stream(func(w io.Writer) bool {
+//		progress, ok := <-job.Progress
+//		if !ok {
+//			return false
+//		}
+//		c.SSEvent("progress", progress)
+//		return true
+//	})
+func (c *Context) Stream(step func(w io.Writer) bool) bool {
+	c.setSSEHeaders()
+	writer := c.Writer
+	flusher, _ := writer.(http.Flusher)
+	done := c.Request.Context().Done()
+	var mutex sync.Mutex
+	stopped := false
+	flush := func(data []byte) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		// the response belongs to the next request once Stream returned
+		if stopped {
+			return
+		}
+		_, _ = writer.Write(data)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	// send the headers
+	flush(nil)
+
+	heartbeat := DefaultSSEHeartbeat
+	if c.engine != nil {
+		heartbeat = c.engine.config.SSEHeartbeat
+	}
+	if heartbeat > 0 {
+		stop := make(chan struct{})
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ticker := time.NewTicker(heartbeat)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					flush([]byte(": heartbeat\n\n"))
+				case <-stop:
+					return
+				case <-done:
+					return
+				}
+			}
+		}()
+		// no heartbeat is written after Stream returns
+		defer func() {
+			mutex.Lock()
+			stopped = true
+			mutex.Unlock()
+			close(stop)
+			wg.Wait()
+		}()
+	}
+
+	sw := &streamWriter{ResponseWriter: writer}
+	defer func() {
+		c.Writer = writer
+	}()
+	for {
+		select {
+		case <-done:
+			return true
+		default:
+		}
+		sw.buf.Reset()
+		c.Writer = sw
+		keepOpen := step(sw)
+		c.Writer = writer
+		if sw.buf.Len() > 0 {
+			flush(sw.buf.Bytes())
+		}
+		if !keepOpen {
+			select {
+			case <-done:
+				return true
+			default:
+				return false
+			}
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package seng
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestServerSentEventEncode(t *testing.T) {
+	tests := []struct {
+		name  string
+		event ServerSentEvent
+		want  string
+	}{
+		{"empty", ServerSentEvent{}, "data: \n\n"},
+		{"string", ServerSentEvent{Event: "tick", Data: "1"}, "event: tick\ndata: 1\n\n"},
+		{"bytes", ServerSentEvent{Data: []byte("raw")}, "data: raw\n\n"},
+		{"json", ServerSentEvent{Data: Map{"a": 1}}, "data: {\"a\":1}\n\n"},
+		{"multiline", ServerSentEvent{Data: "a\r\nb\nc"}, "data: a\ndata: b\ndata: c\n\n"},
+		{"all fields", ServerSentEvent{ID: "7", Event: "up", Data: "x", Retry: 1500 * time.Millisecond},
+			"id: 7\nevent: up\nretry: 1500\ndata: x\n\n"},
+		{"sanitized", ServerSentEvent{ID: "1\n2", Event: "a\r\ndata: b", Data: "x"},
+			"id: 12\nevent: adata: b\ndata: x\n\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(strings.Builder)
+			if err := tt.event.encode(buf); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("encode = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+	if err := (ServerSentEvent{Data: make(chan int)}).encode(io.Discard); err == nil {
+		t.Error("encode(chan) = nil error")
+	}
+}
+
+// newRecordedContext returns a Context without engine writing to w
+func newRecordedContext(headers ...string) (*Context, *httptest.ResponseRecorder) {
+	w := httptest.NewRecorder()
+	c := newTestContext(headers...)
+	c.Writer = w
+	return c, w
+}
+
+func TestSSEvent(t *testing.T) {
+	c, w := newRecordedContext(HeaderLastEventID, "41")
+	if err := c.SSEvent("progress", Map{"percent": 50}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SendEvent(ServerSentEvent{ID: "42", Data: "done"}); err != nil {
+		t.Fatal(err)
+	}
+	want := "event: progress\ndata: {\"percent\":50}\n\nid: 42\ndata: done\n\n"
+	if w.Body.String() != want {
+		t.Errorf("body = %q, want %q", w.Body, want)
+	}
+	for key, value := range map[string]string{
+		HeaderContentType:     MIMETextEventStream,
+		HeaderCacheControl:    "no-cache",
+		HeaderXAccelBuffering: "no",
+	} {
+		if got := w.Header().Get(key); got != value {
+			t.Errorf("%s = %q, want %q", key, got, value)
+		}
+	}
+	if !w.Flushed {
+		t.Error("event not flushed")
+	}
+	if c.LastEventID() != "41" {
+		t.Errorf("LastEventID = %q", c.LastEventID())
+	}
+}
+
+func TestStream(t *testing.T) {
+	tests := []struct {
+		name      string
+		steps     []string
+		cancel    bool
+		wantBody  string
+		wantGone  bool
+		wantSteps int
+	}{
+		{"single step", []string{"data: a\n\n"}, false, "data: a\n\n", false, 1},
+		{"several steps", []string{"data: a\n\n", "", "data: b\n\n"}, false, "data: a\n\ndata: b\n\n", false, 3},
+		{"client gone", []string{"data: a\n\n", "data: b\n\n"}, true, "", true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := newRecordedContext()
+			if tt.cancel {
+				ctx, cancel := context.WithCancel(c.Request.Context())
+				cancel()
+				c.Request = c.Request.WithContext(ctx)
+			}
+			steps := 0
+			gone := c.Stream(func(w io.Writer) bool {
+				_, _ = io.WriteString(w, tt.steps[steps])
+				steps++
+				return steps < len(tt.steps)
+			})
+			if gone != tt.wantGone {
+				t.Errorf("Stream = %t, want %t", gone, tt.wantGone)
+			}
+			if steps != tt.wantSteps {
+				t.Errorf("steps = %d, want %d", steps, tt.wantSteps)
+			}
+			if w.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", w.Body, tt.wantBody)
+			}
+			if w.Header().Get(HeaderContentType) != MIMETextEventStream {
+				t.Errorf("Content-Type = %q", w.Header().Get(HeaderContentType))
+			}
+		})
+	}
+}
+
+// countingWriter counts the writes to a response
+type countingWriter struct {
+	http.ResponseWriter
+	mutex  sync.Mutex
+	writes int
+	body   strings.Builder
+}
+
+func (w *countingWriter) Write(data []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.writes++
+	return w.body.Write(data)
+}
+
+func (w *countingWriter) count() (int, string) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.writes, w.body.String()
+}
+
+func TestStreamHeartbeat(t *testing.T) {
+	tests := []struct {
+		name          string
+		heartbeat     time.Duration
+		wantHeartbeat bool
+	}{
+		{"enabled", 5 * time.Millisecond, true},
+		{"disabled", -1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &countingWriter{ResponseWriter: httptest.NewRecorder()}
+			e := New(Config{SSEHeartbeat: tt.heartbeat})
+			var writes int
+			e.GET("/", func(c *Context) error {
+				c.Writer = w
+				c.Stream(func(io.Writer) bool {
+					time.Sleep(50 * time.Millisecond)
+					return false
+				})
+				writes, _ = w.count()
+				return nil
+			})
+			serve(t, e, http.MethodGet, "/", "")
+			// nothing is written once Stream returned
+			time.Sleep(30 * time.Millisecond)
+			after, body := w.count()
+			if after != writes {
+				t.Errorf("%d writes after Stream returned", after-writes)
+			}
+			if got := strings.Contains(body, ": heartbeat\n\n"); got != tt.wantHeartbeat {
+				t.Errorf("heartbeat = %t, want %t, body %q", got, tt.wantHeartbeat, body)
+			}
+		})
+	}
+}
+
+func TestStreamWithoutEngine(t *testing.T) {
+	c := newTestContext()
+	if gone := c.Stream(func(w io.Writer) bool { return false }); gone {
+		t.Error("Stream = true, want false")
+	}
+}
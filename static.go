@@ -0,0 +1,178 @@
+package seng
+
+import (
+	"html"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StaticConfig options of RouterGroup.Static and StaticFS
+type StaticConfig struct {
+	// Index files served for directories
+	// Default: ["index.html"]
+	Index []string `json:"index"`
+	// Browse lists directories without index file
+	// Default: false
+	Browse bool `json:"browse"`
+	// MaxAge of the Cache-Control header, 0 sends no Cache-Control
+	MaxAge time.Duration `json:"max_age"`
+	// CacheControl replaces the Cache-Control header of MaxAge, e.g. "no-cache"
+	CacheControl string `json:"cache_control"`
+	// DisableETag disables the ETag header and If-None-Match
+	DisableETag bool `json:"disable_etag"`
+	// DisableLastModified disables the Last-Modified header and If-Modified-Since
+	DisableLastModified bool `json:"disable_last_modified"`
+	// SPA serves the first Index file of the root for missing files when the
+	// Accept header contains text/html, so that a single-page app handles its routes
+	SPA bool `json:"spa"`
+	// Download sends the files as attachments
+	Download bool `json:"download"`
+	// Precompressed serves the .br and .gz siblings of files, e.g. app.js.br
+	// for app.js, to clients accepting the encoding
+	Precompressed bool `json:"precompressed"`
+	// CompressCacheDir enables gzip compression of text files without a .gz
+	// sibling on first request, the results are kept in this directory
+	CompressCacheDir string `json:"compress_cache_dir"`
+}
+
+// DefaultStaticIndex default StaticConfig.Index
+var DefaultStaticIndex = []string{"index.html"}
+
+// staticHandler serves the files of fsys
+type staticHandler struct {
+	fsys         fs.FS
+	config       StaticConfig
+	cacheControl string
+	// gzip files of CompressCacheDir by name
+	compressed sync.Map
+}
+
+// newStaticHandler creates the handler of config for fsys
+func newStaticHandler(fsys fs.FS, config StaticConfig) *staticHandler {
+	if config.Index == nil {
+		config.Index = DefaultStaticIndex
+	}
+	cacheControl := config.CacheControl
+	if cacheControl == "" && config.MaxAge > 0 {
+		cacheControl = "public, max-age=" + strconv.FormatInt(int64(config.MaxAge/time.Second), 10)
+	}
+	return &staticHandler{fsys: fsys, config: config, cacheControl: cacheControl}
+}
+
+// handle serves the file of the filepath param
+func (h *staticHandler) handle(c *Context) error {
+	name := strings.TrimPrefix(path.Clean("/"+c.Params["filepath"]), "/")
+	if name == "" {
+		name = "."
+	}
+	if !fs.ValidPath(name) {
+		return NewError(http.StatusBadRequest)
+	}
+	stat, err := fs.Stat(h.fsys, name)
+	// scripts and images are requested with Accept: */*, pages with text/html
+	if os.IsNotExist(err) && h.config.SPA && len(h.config.Index) > 0 &&
+		strings.Contains(c.GetHeader(HeaderAccept), ContentTypeTextHtml) {
+		return h.serveFile(c, h.config.Index[0])
+	}
+	if err != nil {
+		return fileError(err)
+	}
+	if !stat.IsDir() {
+		return h.serveFile(c, name)
+	}
+
+	// directories are served with a trailing slash so that relative links work.
+	// The target is relative, the request path could be //host for Static("/").
+	if !strings.HasSuffix(c.Request.URL.Path, "/") {
+		target := (&url.URL{Path: "./" + path.Base(c.Request.URL.Path) + "/"}).EscapedPath()
+		if c.Request.URL.RawQuery != "" {
+			target += "?" + c.Request.URL.RawQuery
+		}
+		http.Redirect(c.Writer, c.Request, target, http.StatusMovedPermanently)
+		return nil
+	}
+	for _, index := range h.config.Index {
+		indexName := path.Join(name, index)
+		if stat, err := fs.Stat(h.fsys, indexName); err == nil && !stat.IsDir() {
+			return h.serveFile(c, indexName)
+		}
+	}
+	if !h.config.Browse {
+		return NewError(http.StatusNotFound)
+	}
+	return h.browse(c, name)
+}
+
+// serveFile sends the file name, or a compressed version of it
+func (h *staticHandler) serveFile(c *Context, name string) error {
+	file, err := h.openEncoded(c, name)
+	if err != nil {
+		return err
+	}
+	if file == nil {
+		if file, err = h.fsys.Open(name); err != nil {
+			return fileError(err)
+		}
+	}
+	defer file.Close()
+	if h.cacheControl != "" {
+		c.Writer.Header().Set(HeaderCacheControl, h.cacheControl)
+	}
+	if h.config.Download {
+		c.Attachment(path.Base(name))
+	}
+	return c.sendFile(file, !h.config.DisableETag, !h.config.DisableLastModified)
+}
+
+// browse lists the directory name
+func (h *staticHandler) browse(c *Context, name string) error {
+	entries, err := fs.ReadDir(h.fsys, name)
+	if err != nil {
+		return fileError(err)
+	}
+	var b strings.Builder
+	b.WriteString("<!doctype html>\n<meta name=\"viewport\" content=\"width=device-width\">\n<pre>\n")
+	for _, entry := range entries {
+		entryName := entry.Name()
+		if entry.IsDir() {
+			entryName += "/"
+		}
+		// url.URL escapes the name as a path, e.g. "a:b" would be a scheme
+		link := url.URL{Path: entryName}
+		b.WriteString("<a href=\"" + html.EscapeString(link.String()) + "\">" + html.EscapeString(entryName) + "</a>\n")
+	}
+	b.WriteString("</pre>\n")
+	return c.writeContent(MINETextHTML, []byte(b.String()))
+}
+
+// Static serves the files of the directory root under relativePath
+// e.Static("/assets", "./public", seng.StaticConfig{MaxAge: 24 * time.Hour})
+func (g *RouterGroup) Static(relativePath string, root string, config ...StaticConfig) {
+	g.StaticFS(relativePath, os.DirFS(root), config...)
+}
+
+// StaticFS serves the files of fsys under relativePath, e.g. an embed.FS
+//
+//	//go:embed dist
+//	var dist embed.FS
+//	sub, _ := fs.Sub(dist, "dist")
+//	e.StaticFS("/", sub, seng.StaticConfig{SPA: true})
+func (g *RouterGroup) StaticFS(relativePath string, fsys fs.FS, config ...StaticConfig) {
+	var staticConfig StaticConfig
+	if len(config) > 0 {
+		staticConfig = config[0]
+	}
+	handler := newStaticHandler(fsys, staticConfig).handle
+	// the wildcard does not match the root directory
+	for _, urlPattern := range []string{relativePath, path.Join(relativePath, "/*filepath")} {
+		g.GET(urlPattern, handler)
+		g.HEAD(urlPattern, handler)
+	}
+}
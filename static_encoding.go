@@ -0,0 +1,184 @@
+package seng
+
+import (
+	"compress/gzip"
+	"hash/crc32"
+	"io/fs"
+	"io/ioutil"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// minCompressSize files smaller than this are not worth compressing
+const minCompressSize = 1024
+
+// staticEncodings content codings of StaticConfig.Precompressed by preference
+var staticEncodings = []struct {
+	name string
+	ext  string
+}{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// compressedFile a gzip file of StaticConfig.CompressCacheDir
+type compressedFile struct {
+	path    string
+	modTime time.Time
+	size    int64
+}
+
+// openEncoded opens the best compressed version of the file name for the
+// Accept-Encoding header, it returns nil to send the file itself.
+// The Content-Type of name and the Content-Encoding are set on the response.
+func (h *staticHandler) openEncoded(c *Context, name string) (fs.File, error) {
+	if !h.config.Precompressed && h.config.CompressCacheDir == "" {
+		return nil, nil
+	}
+	// the Content-Type of app.js.gz would be application/gzip
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		return nil, nil
+	}
+	var offers []string
+	if h.config.Precompressed {
+		for _, encoding := range staticEncodings {
+			if stat, err := fs.Stat(h.fsys, name+encoding.ext); err == nil && !stat.IsDir() {
+				offers = append(offers, encoding.name)
+			}
+		}
+	}
+	var stat fs.FileInfo
+	if h.config.CompressCacheDir != "" && !containsString(offers, "gzip") && compressible(contentType) {
+		var err error
+		if stat, err = fs.Stat(h.fsys, name); err == nil && stat.Size() >= minCompressSize {
+			offers = append(offers, "gzip")
+		}
+	}
+	if len(offers) == 0 {
+		return nil, nil
+	}
+	header := c.Writer.Header()
+	header.Add(HeaderVary, HeaderAcceptEncoding)
+	// without Accept-Encoding any coding is acceptable, but clients rarely mean it
+	if c.GetHeader(HeaderAcceptEncoding) == "" {
+		return nil, nil
+	}
+	encoding := c.AcceptsEncodings(append(offers, "identity")...)
+	if encoding == "" || encoding == "identity" {
+		return nil, nil
+	}
+
+	var file fs.File
+	var err error
+	if encoding == "gzip" && stat != nil {
+		file, err = h.openCompressed(name, stat)
+	} else {
+		for _, staticEncoding := range staticEncodings {
+			if staticEncoding.name == encoding {
+				file, err = h.fsys.Open(name + staticEncoding.ext)
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if header.Get(HeaderContentType) == "" {
+		header.Set(HeaderContentType, contentType)
+	}
+	header.Set(HeaderContentEncoding, encoding)
+	return file, nil
+}
+
+// openCompressed opens the gzip file of name in CompressCacheDir, compressing
+// name when it has changed. The files are named by the checksum of the
+// content, so they stay valid across restarts. Old versions are not removed.
+func (h *staticHandler) openCompressed(name string, stat fs.FileInfo) (fs.File, error) {
+	if value, ok := h.compressed.Load(name); ok {
+		cached := value.(compressedFile)
+		if cached.modTime.Equal(stat.ModTime()) && cached.size == stat.Size() {
+			if file, err := os.Open(cached.path); err == nil {
+				return file, nil
+			}
+		}
+	}
+	data, err := fs.ReadFile(h.fsys, name)
+	if err != nil {
+		return nil, fileError(err)
+	}
+	cachePath := filepath.Join(h.config.CompressCacheDir, filepath.FromSlash(name)) +
+		"." + strconv.FormatUint(uint64(crc32.ChecksumIEEE(data)), 16) + ".gz"
+	if _, err := os.Stat(cachePath); os.IsNotExist(err) {
+		if err := writeGzipFile(cachePath, data, stat.ModTime()); err != nil {
+			return nil, err
+		}
+	}
+	h.compressed.Store(name, compressedFile{path: cachePath, modTime: stat.ModTime(), size: stat.Size()})
+	return os.Open(cachePath)
+}
+
+// writeGzipFile compresses data to path, the file is written to a temporary
+// file first so that concurrent requests never read a partial file
+func writeGzipFile(path string, data []byte, modTime time.Time) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	writer, err := gzip.NewWriterLevel(tmp, gzip.BestCompression)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := writer.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	// Last-Modified follows the original file
+	if !modTime.IsZero() {
+		if err := os.Chtimes(tmp.Name(), modTime, modTime); err != nil {
+			return err
+		}
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// compressible reports whether files of contentType are worth compressing
+func compressible(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if strings.HasPrefix(mediaType, "text/") {
+		return true
+	}
+	// application/javascript, application/ld+json, image/svg+xml, application/wasm
+	for _, suffix := range []string{"javascript", "json", "xml", "wasm"} {
+		if strings.HasSuffix(mediaType, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsString reports whether s is in values
+func containsString(values []string, s string) bool {
+	for _, value := range values {
+		if value == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,182 @@
+package seng
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestStaticPrecompressed(t *testing.T) {
+	fsys := fstest.MapFS{
+		"app.js":          {Data: []byte("plain js")},
+		"app.js.br":       {Data: []byte("br js")},
+		"app.js.gz":       {Data: []byte("gzip js")},
+		"style.css":       {Data: []byte("plain css")},
+		"style.css.gz":    {Data: []byte("gzip css")},
+		"logo.png":        {Data: []byte("plain png")},
+		"data.unknown":    {Data: []byte("plain unknown")},
+		"data.unknown.gz": {Data: []byte("gzip unknown")},
+	}
+	tests := []struct {
+		name           string
+		target         string
+		acceptEncoding string
+		wantBody       string
+		wantEncoding   string
+		wantType       string
+		wantVary       bool
+	}{
+		{"br preferred", "/app.js", "gzip, br", "br js", "br", "text/javascript; charset=utf-8", true},
+		{"gzip", "/app.js", "gzip", "gzip js", "gzip", "text/javascript; charset=utf-8", true},
+		{"q values", "/app.js", "br;q=0.5, gzip", "gzip js", "gzip", "text/javascript; charset=utf-8", true},
+		{"identity preferred", "/app.js", "identity, gzip;q=0.5", "plain js", "", "text/javascript; charset=utf-8", true},
+		{"no acceptable encoding", "/app.js", "deflate", "plain js", "", "text/javascript; charset=utf-8", true},
+		{"no Accept-Encoding", "/app.js", "", "plain js", "", "text/javascript; charset=utf-8", true},
+		{"only gzip sibling", "/style.css", "br, gzip", "gzip css", "gzip", "text/css; charset=utf-8", true},
+		{"no sibling", "/logo.png", "br, gzip", "plain png", "", "image/png", false},
+		{"unknown type", "/data.unknown", "gzip", "plain unknown", "", "", false},
+		{"sibling requested", "/app.js.gz", "gzip", "gzip js", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New()
+			e.StaticFS("/", fsys, StaticConfig{Precompressed: true})
+			var headers []string
+			if tt.acceptEncoding != "" {
+				headers = []string{HeaderAcceptEncoding, tt.acceptEncoding}
+			}
+			w := serve(t, e, http.MethodGet, tt.target, "", headers...)
+			if w.Code != http.StatusOK || w.Body.String() != tt.wantBody {
+				t.Fatalf("%d %q, want %q", w.Code, w.Body, tt.wantBody)
+			}
+			if got := w.Header().Get(HeaderContentEncoding); got != tt.wantEncoding {
+				t.Errorf("Content-Encoding = %q, want %q", got, tt.wantEncoding)
+			}
+			if got := w.Header().Get(HeaderContentType); tt.wantType != "" && got != tt.wantType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantType)
+			}
+			if got := w.Header().Get(HeaderVary) == HeaderAcceptEncoding; got != tt.wantVary {
+				t.Errorf("Vary = %q, want Accept-Encoding %v", w.Header().Get(HeaderVary), tt.wantVary)
+			}
+		})
+	}
+
+	// the versions of a file have different ETags
+	e := New()
+	e.StaticFS("/", fsys, StaticConfig{Precompressed: true})
+	etags := map[string]bool{}
+	for _, encoding := range []string{"br", "gzip", "identity"} {
+		etags[serve(t, e, http.MethodGet, "/app.js", "", HeaderAcceptEncoding, encoding).Header().Get(HeaderETag)] = true
+	}
+	if len(etags) != 3 {
+		t.Errorf("ETags = %v, want 3 different ones", etags)
+	}
+}
+
+// gunzip returns the decompressed data
+func gunzip(t *testing.T, data string) string {
+	t.Helper()
+	reader, err := gzip.NewReader(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	decompressed, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(decompressed)
+}
+
+func TestStaticCompressCacheDir(t *testing.T) {
+	large := strings.Repeat("body { color: red; }\n", 100)
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"large.css":   {Data: []byte(large), ModTime: modTime},
+		"small.css":   {Data: []byte("a{}"), ModTime: modTime},
+		"image.png":   {Data: []byte(strings.Repeat("x", 2048)), ModTime: modTime},
+		"gz.css":      {Data: []byte(large), ModTime: modTime},
+		"gz.css.gz":   {Data: []byte("precompressed"), ModTime: modTime},
+		"data/a.json": {Data: []byte(strings.Repeat(`{"a":1}`, 200)), ModTime: modTime},
+	}
+	dir := t.TempDir()
+	e := New()
+	e.StaticFS("/", fsys, StaticConfig{Precompressed: true, CompressCacheDir: dir})
+	tests := []struct {
+		name         string
+		target       string
+		wantEncoding string
+		// want the decompressed body
+		want string
+	}{
+		{"compressed", "/large.css", "gzip", large},
+		{"nested", "/data/a.json", "gzip", strings.Repeat(`{"a":1}`, 200)},
+		{"too small", "/small.css", "", "a{}"},
+		{"not compressible", "/image.png", "", strings.Repeat("x", 2048)},
+		{"precompressed sibling", "/gz.css", "gzip", ""},
+	}
+	for _, tt := range tests {
+		w := serve(t, e, http.MethodGet, tt.target, "", HeaderAcceptEncoding, "gzip")
+		if got := w.Header().Get(HeaderContentEncoding); got != tt.wantEncoding {
+			t.Errorf("%s: Content-Encoding = %q, want %q", tt.name, got, tt.wantEncoding)
+			continue
+		}
+		body := w.Body.String()
+		switch {
+		case tt.name == "precompressed sibling":
+			if body != "precompressed" {
+				t.Errorf("%s: body = %q", tt.name, body)
+			}
+		case tt.wantEncoding == "gzip":
+			if got := gunzip(t, body); got != tt.want {
+				t.Errorf("%s: decompressed body = %q", tt.name, got)
+			}
+		case body != tt.want:
+			t.Errorf("%s: body = %q", tt.name, body)
+		}
+	}
+	cached, _ := filepath.Glob(filepath.Join(dir, "*.gz"))
+	if len(cached) != 1 || !strings.HasPrefix(filepath.Base(cached[0]), "large.css.") {
+		t.Errorf("cached files = %v", cached)
+	}
+
+	// the file is compressed again when it changes
+	changed := strings.Repeat("p { margin: 0; }\n", 100)
+	fsys["large.css"] = &fstest.MapFile{Data: []byte(changed), ModTime: modTime.Add(time.Hour)}
+	w := serve(t, e, http.MethodGet, "/large.css", "", HeaderAcceptEncoding, "gzip")
+	if got := gunzip(t, w.Body.String()); got != changed {
+		t.Errorf("body after change = %q", got)
+	}
+	if got := w.Header().Get("Last-Modified"); got != modTime.Add(time.Hour).Format(http.TimeFormat) {
+		t.Errorf("Last-Modified = %q", got)
+	}
+	if cached, _ := filepath.Glob(filepath.Join(dir, "large.css.*.gz")); len(cached) != 2 {
+		t.Errorf("cached versions = %v", cached)
+	}
+}
+
+func TestCompressible(t *testing.T) {
+	tests := []struct {
+		contentType string
+		want        bool
+	}{
+		{"text/css; charset=utf-8", true},
+		{"text/plain", true},
+		{"application/javascript", true},
+		{"application/ld+json", true},
+		{"image/svg+xml", true},
+		{"application/wasm", true},
+		{"image/png", false},
+		{"application/zip", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := compressible(tt.contentType); got != tt.want {
+			t.Errorf("compressible(%q) = %v, want %v", tt.contentType, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package seng
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// testStaticFS files served by the static tests
+func testStaticFS() fstest.MapFS {
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	return fstest.MapFS{
+		"index.html":         {Data: []byte("<h1>home</h1>"), ModTime: modTime},
+		"app.js":             {Data: []byte("console.log(1)"), ModTime: modTime},
+		"assets/logo.svg":    {Data: []byte("<svg></svg>"), ModTime: modTime},
+		"assets/a?b.txt":     {Data: []byte("query"), ModTime: modTime},
+		"docs/index.html":    {Data: []byte("<h1>docs</h1>"), ModTime: modTime},
+		"docs/guide/a:b.txt": {Data: []byte("guide"), ModTime: modTime},
+		"q?dir/file.txt":     {Data: []byte("file"), ModTime: modTime},
+	}
+}
+
+func TestStatic(t *testing.T) {
+	tests := []struct {
+		name       string
+		prefix     string
+		config     StaticConfig
+		target     string
+		headers    []string
+		wantCode   int
+		wantBody   string
+		wantHeader []string
+	}{
+		{"file", "/static", StaticConfig{}, "/static/app.js", nil, http.StatusOK, "console.log(1)",
+			[]string{HeaderContentType, "text/javascript; charset=utf-8"}},
+		{"root index", "/static", StaticConfig{}, "/static/", nil, http.StatusOK, "<h1>home</h1>", nil},
+		{"directory index", "/static", StaticConfig{}, "/static/docs/", nil, http.StatusOK, "<h1>docs</h1>", nil},
+		{"root redirect", "/static", StaticConfig{}, "/static", nil, http.StatusMovedPermanently, "",
+			[]string{"Location", "/static/"}},
+		{"directory redirect", "/static", StaticConfig{}, "/static/docs?lang=en", nil, http.StatusMovedPermanently, "",
+			[]string{"Location", "/static/docs/?lang=en"}},
+		{"escaped directory redirect", "/", StaticConfig{}, "/q%3Fdir", nil, http.StatusMovedPermanently, "",
+			[]string{"Location", "/q%3Fdir/"}},
+		// a redirect to //assets/ would be a redirect to the host assets
+		{"no open redirect", "/", StaticConfig{}, "//assets", nil, http.StatusMovedPermanently, "",
+			[]string{"Location", "/assets/"}},
+		{"no open redirect with dots", "/", StaticConfig{}, "//assets/../docs", nil, http.StatusMovedPermanently, "",
+			[]string{"Location", "/docs/"}},
+		{"missing", "/static", StaticConfig{}, "/static/missing.js", nil, http.StatusNotFound, "", nil},
+		{"no index", "/static", StaticConfig{}, "/static/docs/guide/", nil, http.StatusNotFound, "", nil},
+		{"custom index", "/static", StaticConfig{Index: []string{"logo.svg"}}, "/static/assets/", nil, http.StatusOK, "<svg></svg>", nil},
+		{"browse", "/static", StaticConfig{Browse: true}, "/static/docs/guide/", nil, http.StatusOK,
+			"<!doctype html>\n<meta name=\"viewport\" content=\"width=device-width\">\n<pre>\n<a href=\"./a:b.txt\">a:b.txt</a>\n</pre>\n", nil},
+		{"browse escapes", "/static", StaticConfig{Browse: true, Index: []string{}}, "/static/assets/", nil, http.StatusOK,
+			"<!doctype html>\n<meta name=\"viewport\" content=\"width=device-width\">\n<pre>\n<a href=\"a%3Fb.txt\">a?b.txt</a>\n<a href=\"logo.svg\">logo.svg</a>\n</pre>\n", nil},
+		{"spa", "/", StaticConfig{SPA: true}, "/users/1", []string{HeaderAccept, "text/html,*/*"}, http.StatusOK, "<h1>home</h1>", nil},
+		{"spa asset", "/", StaticConfig{SPA: true}, "/missing.js", []string{HeaderAccept, "*/*"}, http.StatusNotFound, "", nil},
+		{"max age", "/static", StaticConfig{MaxAge: time.Hour}, "/static/app.js", nil, http.StatusOK, "console.log(1)",
+			[]string{HeaderCacheControl, "public, max-age=3600"}},
+		{"cache control", "/static", StaticConfig{MaxAge: time.Hour, CacheControl: "no-cache"}, "/static/app.js", nil, http.StatusOK, "console.log(1)",
+			[]string{HeaderCacheControl, "no-cache"}},
+		{"download", "/static", StaticConfig{Download: true}, "/static/assets/logo.svg", nil, http.StatusOK, "<svg></svg>",
+			[]string{HeaderContentDisposition, `attachment; filename="logo.svg"`}},
+		{"last modified", "/static", StaticConfig{}, "/static/app.js", []string{"If-Modified-Since", "Tue, 02 Jan 2024 03:04:05 GMT"},
+			http.StatusNotModified, "", nil},
+		{"last modified disabled", "/static", StaticConfig{DisableLastModified: true, DisableETag: true}, "/static/app.js",
+			[]string{"If-Modified-Since", "Tue, 02 Jan 2024 03:04:05 GMT"}, http.StatusOK, "console.log(1)",
+			[]string{"Last-Modified", "", HeaderETag, ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New()
+			e.StaticFS(tt.prefix, testStaticFS(), tt.config)
+			w := serve(t, e, http.MethodGet, tt.target, "", tt.headers...)
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", w.Body, tt.wantBody)
+			}
+			for i := 0; i+1 < len(tt.wantHeader); i += 2 {
+				if got := w.Header().Get(tt.wantHeader[i]); got != tt.wantHeader[i+1] {
+					t.Errorf("%s = %q, want %q", tt.wantHeader[i], got, tt.wantHeader[i+1])
+				}
+			}
+		})
+	}
+}
+
+func TestStaticETagAndHead(t *testing.T) {
+	e := New()
+	e.StaticFS("/static", testStaticFS())
+	w := serve(t, e, http.MethodGet, "/static/app.js", "")
+	etag := w.Header().Get(HeaderETag)
+	if etag == "" || w.Header().Get("Last-Modified") == "" {
+		t.Fatalf("ETag %q, Last-Modified %q", etag, w.Header().Get("Last-Modified"))
+	}
+	tests := []struct {
+		name     string
+		method   string
+		headers  []string
+		wantCode int
+		wantBody string
+	}{
+		{"etag", http.MethodGet, []string{"If-None-Match", etag}, http.StatusNotModified, ""},
+		{"other etag", http.MethodGet, []string{"If-None-Match", `"other"`}, http.StatusOK, "console.log(1)"},
+		{"head", http.MethodHead, nil, http.StatusOK, ""},
+		{"range", http.MethodGet, []string{"Range", "bytes=0-6"}, http.StatusPartialContent, "console"},
+	}
+	for _, tt := range tests {
+		w := serve(t, e, tt.method, "/static/app.js", "", tt.headers...)
+		if w.Code != tt.wantCode || w.Body.String() != tt.wantBody {
+			t.Errorf("%s: %d %q, want %d %q", tt.name, w.Code, w.Body, tt.wantCode, tt.wantBody)
+		}
+	}
+	if got := serve(t, e, http.MethodHead, "/static/app.js", "").Header().Get("Content-Length"); got != "14" {
+		t.Errorf("HEAD Content-Length = %q", got)
+	}
+}
+
+func TestStaticDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "robots.txt"), []byte("User-agent: *"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	e := New()
+	e.Static("/", dir)
+	tests := []struct {
+		target   string
+		wantCode int
+		wantBody string
+	}{
+		{"/robots.txt", http.StatusOK, "User-agent: *"},
+		{"/../robots.txt", http.StatusOK, "User-agent: *"},
+		{"/missing.txt", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		w := serve(t, e, http.MethodGet, tt.target, "")
+		if w.Code != tt.wantCode || (tt.wantBody != "" && !strings.Contains(w.Body.String(), tt.wantBody)) {
+			t.Errorf("%s: %d %q, want %d %q", tt.target, w.Code, w.Body, tt.wantCode, tt.wantBody)
+		}
+	}
+}
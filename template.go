@@ -1,13 +1,219 @@
 package seng
 
-import "html/template"
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
 
-// SetFuncMap set template.FuncMap
+// templateSource the files templates were loaded from, kept to reload them
+// in debug mode
+type templateSource struct {
+	// fsys nil for the OS file system
+	fsys     fs.FS
+	patterns []string
+	// files of LoadHTMLFiles
+	files []string
+}
+
+// names returns the files matched by the source, sorted
+func (s *templateSource) names() ([]string, error) {
+	names := append([]string(nil), s.files...)
+	for _, pattern := range s.patterns {
+		var matches []string
+		var err error
+		if s.fsys != nil {
+			matches, err = fs.Glob(s.fsys, pattern)
+		} else {
+			matches, err = filepath.Glob(pattern)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("pattern matches no files: %#q", pattern)
+		}
+		names = append(names, matches...)
+	}
+	if len(names) == 0 {
+		return nil, errors.New("no files named")
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// version returns a string which changes when a file is added, removed or modified
+func (s *templateSource) version() (string, error) {
+	names, err := s.names()
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, name := range names {
+		var info fs.FileInfo
+		if s.fsys != nil {
+			info, err = fs.Stat(s.fsys, name)
+		} else {
+			info, err = os.Stat(name)
+		}
+		if err != nil {
+			return "", err
+		}
+		writeFileVersion(&b, name, info)
+	}
+	return b.String(), nil
+}
+
+// parse parses the files of the source
+func (s *templateSource) parse(funcs template.FuncMap) (*template.Template, error) {
+	names, err := s.names()
+	if err != nil {
+		return nil, err
+	}
+	t := template.New("").Funcs(funcs)
+	if s.fsys != nil {
+		return t.ParseFS(s.fsys, names...)
+	}
+	return t.ParseFiles(names...)
+}
+
+// writeFileVersion writes the name, modification time and size of a file
+func writeFileVersion(b *strings.Builder, name string, info fs.FileInfo) {
+	b.WriteString(name)
+	b.WriteByte(0)
+	b.WriteString(strconv.FormatInt(info.ModTime().UnixNano(), 16))
+	b.WriteByte(0)
+	b.WriteString(strconv.FormatInt(info.Size(), 16))
+	b.WriteByte(0)
+}
+
+// SetFuncMap set template.FuncMap, it must be called before loading templates
 func (e *Engine) SetFuncMap(funcMap template.FuncMap) {
 	e.funcMap = funcMap
 }
 
-// LoadHTMLGlob parse glob
-func (e *Engine) LoadHTMLGlob(pattern string) {
-	e.htmlTemplates = template.Must(template.New("").Funcs(e.funcMap).ParseGlob(pattern))
+// LoadHTMLGlob parses the templates matching pattern.
+// In debug mode changed templates are parsed again before rendering.
+func (e *Engine) LoadHTMLGlob(pattern string) error {
+	return e.loadHTML(&templateSource{patterns: []string{pattern}})
+}
+
+// LoadHTMLFiles parses the templates files
+func (e *Engine) LoadHTMLFiles(files ...string) error {
+	return e.loadHTML(&templateSource{files: files})
+}
+
+// LoadHTMLFS parses the templates of fsys matching patterns, e.g. an embed.FS
+//
+//	//go:embed templates
+//	var templates embed.FS
+//	e.LoadHTMLFS(templates, "templates/*.html")
+func (e *Engine) LoadHTMLFS(fsys fs.FS, patterns ...string) error {
+	return e.loadHTML(&templateSource{fsys: fsys, patterns: patterns})
+}
+
+// loadHTML parses the templates of source
+func (e *Engine) loadHTML(source *templateSource) error {
+	version, err := source.version()
+	if err != nil {
+		return fmt.Errorf("seng: templates: %w", err)
+	}
+	funcs := e.templateFuncs()
+	t, err := source.parse(funcs)
+	if err != nil {
+		return fmt.Errorf("seng: templates: %w", err)
+	}
+	e.templateMutex.Lock()
+	e.htmlTemplates = newTemplateSet(t, funcs)
+	e.htmlSource = source
+	e.htmlVersion = version
+	e.templateMutex.Unlock()
+	return nil
+}
+
+// templates returns the HTML templates, parsed again with
+// debug mode when their files changed
+func (e *Engine) templates() (*templateSet, error) {
+	e.templateMutex.RLock()
+	t, source, version := e.htmlTemplates, e.htmlSource, e.htmlVersion
+	e.templateMutex.RUnlock()
+	if source == nil || !e.config.Debug {
+		return t, nil
+	}
+	current, err := source.version()
+	if err != nil {
+		return nil, fmt.Errorf("seng: templates: %w", err)
+	}
+	if current == version {
+		return t, nil
+	}
+	funcs := e.templateFuncs()
+	parsed, err := source.parse(funcs)
+	if err != nil {
+		return nil, fmt.Errorf("seng: templates: %w", err)
+	}
+	t = newTemplateSet(parsed, funcs)
+	e.templateMutex.Lock()
+	// keep templates loaded again meanwhile
+	if e.htmlSource == source {
+		e.htmlTemplates = t
+		e.htmlVersion = current
+	}
+	e.templateMutex.Unlock()
+	return t, nil
+}
+
+// executeTemplate executes the template name of the HTML templates with funcs
+func (e *Engine) executeTemplate(w io.Writer, name string, data interface{}, funcs template.FuncMap) error {
+	templates, err := e.templates()
+	if err != nil {
+		return err
+	}
+	if templates == nil {
+		return errors.New("seng: HTML templates are not loaded")
+	}
+	return templates.execute(w, name, data, funcs)
+}
+
+// templateSet parsed templates with clones to execute them with the
+// functions of a request. Executed templates cannot be cloned, so the parsed
+// ones never are, and the clones are reused instead of cloning the whole set
+// for every render.
+type templateSet struct {
+	templates *template.Template
+	// funcs the templates were parsed with
+	funcs  template.FuncMap
+	clones sync.Pool
+}
+
+func newTemplateSet(t *template.Template, funcs template.FuncMap) *templateSet {
+	return &templateSet{templates: t, funcs: funcs}
+}
+
+// Lookup returns the template name or nil
+func (s *templateSet) Lookup(name string) *template.Template {
+	return s.templates.Lookup(name)
+}
+
+// execute executes the template name of a clone with funcs replacing the
+// parsed functions of the same name
+func (s *templateSet) execute(w io.Writer, name string, data interface{}, funcs template.FuncMap) error {
+	t, _ := s.clones.Get().(*template.Template)
+	if t == nil {
+		var err error
+		if t, err = s.templates.Clone(); err != nil {
+			return err
+		}
+	}
+	defer s.clones.Put(t)
+	// functions of the previous render must not leak into this one
+	return t.Funcs(s.funcs).Funcs(funcs).ExecuteTemplate(w, name, data)
 }
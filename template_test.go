@@ -0,0 +1,190 @@
+package seng
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestLoadHTML(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"index.html": `<h1>{{.}}</h1>`,
+		"about.html": `{{define "about"}}<p>{{upper .}}</p>{{end}}`,
+		"bad.tmpl":   `{{if}}`,
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fsys := fstest.MapFS{
+		"templates/index.html": {Data: []byte(`<h1>{{.}}</h1>`)},
+		"templates/about.html": {Data: []byte(`{{define "about"}}<p>{{upper .}}</p>{{end}}`)},
+	}
+	tests := []struct {
+		name    string
+		load    func(e *Engine) error
+		wantErr bool
+	}{
+		{"glob", func(e *Engine) error { return e.LoadHTMLGlob(filepath.Join(dir, "*.html")) }, false},
+		{"files", func(e *Engine) error {
+			return e.LoadHTMLFiles(filepath.Join(dir, "index.html"), filepath.Join(dir, "about.html"))
+		}, false},
+		{"fs", func(e *Engine) error { return e.LoadHTMLFS(fsys, "templates/*.html") }, false},
+		{"glob without match", func(e *Engine) error { return e.LoadHTMLGlob(filepath.Join(dir, "*.none")) }, true},
+		{"parse error", func(e *Engine) error { return e.LoadHTMLGlob(filepath.Join(dir, "*.tmpl")) }, true},
+		{"missing file", func(e *Engine) error { return e.LoadHTMLFiles(filepath.Join(dir, "missing.html")) }, true},
+		{"no files", func(e *Engine) error { return e.LoadHTMLFiles() }, true},
+		{"fs bad pattern", func(e *Engine) error { return e.LoadHTMLFS(fsys, "[") }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New()
+			e.SetFuncMap(map[string]interface{}{"upper": strings.ToUpper})
+			err := tt.load(e)
+			if tt.wantErr {
+				if err == nil || !strings.HasPrefix(err.Error(), "seng: templates:") {
+					t.Errorf("load = %v, want an error", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			e.GET("/", func(c *Context) error { return c.HTML("index.html", "<home>") })
+			e.GET("/about", func(c *Context) error { return c.HTML("about", "us") })
+			for path, want := range map[string]string{"/": "<h1>&lt;home&gt;</h1>", "/about": "<p>US</p>"} {
+				w := serve(t, e, http.MethodGet, path, "")
+				if w.Code != http.StatusOK || w.Body.String() != want {
+					t.Errorf("%s = %d %q, want %q", path, w.Code, w.Body, want)
+				}
+			}
+		})
+	}
+
+	e := New()
+	e.GET("/", func(c *Context) error { return c.HTML("index.html", nil) })
+	if w := serve(t, e, http.MethodGet, "/", ""); w.Code != http.StatusInternalServerError {
+		t.Errorf("without templates: status = %d", w.Code)
+	}
+}
+
+func TestReloadTemplates(t *testing.T) {
+	tests := []struct {
+		name       string
+		release    bool
+		wantReload bool
+	}{
+		{"debug", false, true},
+		{"release", true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := fstest.MapFS{"index.html": {Data: []byte("v1"), ModTime: time.Unix(1, 0)}}
+			viewsFS := fstest.MapFS{"index.html": {Data: []byte("v1"), ModTime: time.Unix(1, 0)}}
+			templatesEngine := New()
+			if err := templatesEngine.LoadHTMLFS(fsys, "*.html"); err != nil {
+				t.Fatal(err)
+			}
+			viewsEngine := New(Config{Views: NewHTMLViewsFS(viewsFS, ".html")})
+			if tt.release {
+				templatesEngine.SetReleaseMode()
+				viewsEngine.SetReleaseMode()
+			}
+			engines := map[string]*Engine{"templates": templatesEngine, "views": viewsEngine}
+			name := map[string]string{"templates": "index.html", "views": "index"}
+			for kind, e := range engines {
+				kind := kind
+				e.GET("/", func(c *Context) error { return c.HTML(name[kind], nil) })
+			}
+			render := func(kind string) string {
+				return serve(t, engines[kind], http.MethodGet, "/", "").Body.String()
+			}
+			for kind := range engines {
+				if got := render(kind); got != "v1" {
+					t.Fatalf("%s: render = %q", kind, got)
+				}
+			}
+
+			fsys["index.html"] = &fstest.MapFile{Data: []byte("v2"), ModTime: time.Unix(2, 0)}
+			viewsFS["index.html"] = &fstest.MapFile{Data: []byte("v2"), ModTime: time.Unix(2, 0)}
+			want := "v1"
+			if tt.wantReload {
+				want = "v2"
+			}
+			for kind := range engines {
+				if got := render(kind); got != want {
+					t.Errorf("%s: render after change = %q, want %q", kind, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestTemplateSet(t *testing.T) {
+	parseFuncs := template.FuncMap{"user": func() string { return "nobody" }}
+	parsed := template.Must(template.New("page").Funcs(parseFuncs).Parse(`{{user}}`))
+	set := newTemplateSet(parsed, parseFuncs)
+	tests := []struct {
+		name  string
+		funcs template.FuncMap
+		want  string
+	}{
+		{"request funcs", template.FuncMap{"user": func() string { return "ann" }}, "ann"},
+		{"no funcs", nil, "nobody"},
+		{"other request", template.FuncMap{"user": func() string { return "bob" }}, "bob"},
+		{"unrelated funcs", template.FuncMap{"other": func() string { return "" }}, "nobody"},
+	}
+	// the clones are reused, so the functions of a render must not leak
+	for _, tt := range tests {
+		buf := new(bytes.Buffer)
+		if err := set.execute(buf, "page", nil, tt.funcs); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if buf.String() != tt.want {
+			t.Errorf("%s: execute = %q, want %q", tt.name, buf, tt.want)
+		}
+	}
+	if err := set.execute(new(bytes.Buffer), "missing", nil, nil); err == nil {
+		t.Error("execute of a missing template succeeded")
+	}
+	if set.Lookup("page") == nil || set.Lookup("missing") != nil {
+		t.Error("Lookup")
+	}
+}
+
+func TestTemplateRequestFuncsConcurrent(t *testing.T) {
+	e := New(Config{ViewFuncs: map[string]ViewFunc{
+		"user": func(c *Context) interface{} {
+			return func() string { return c.Query("user") }
+		},
+	}})
+	if err := e.LoadHTMLFS(fstest.MapFS{"index.html": {Data: []byte(`{{user}}`)}}, "*.html"); err != nil {
+		t.Fatal(err)
+	}
+	e.GET("/", func(c *Context) error { return c.HTML("index.html", nil) })
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		user := "u" + strconv.Itoa(i)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				w := serve(t, e, http.MethodGet, "/?user="+user, "")
+				if w.Body.String() != user {
+					t.Errorf("render for %s = %q", user, w.Body)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
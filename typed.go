@@ -0,0 +1,90 @@
+package seng
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+)
+
+var (
+	contextType = reflect.TypeOf((*Context)(nil))
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// Typed adapts a function of the form
+//
+//	func(c *seng.Context, in *T) (*R, error)
+//
+// to a Handler.
+// T is bound from query string, body and route params (see Bind) and
+// validated before fn is called, failed validations are 400 Bad Request
+// errors listing the field errors. A non-nil result is rendered with status 200
+// by Negotiate, a nil one results in 204 No Content.
+// Returned errors are passed to the ErrorHandler.
+// Typed panics if fn does not have the expected signature.
+func Typed(fn interface{}) Handler {
+	return newTypedHandler(fn).handle
+}
+
+// typedHandler holds the reflected function of a typed handler
+type typedHandler struct {
+	fn reflect.Value
+	// in is T, out is R with pointers stripped
+	in  reflect.Type
+	out reflect.Type
+}
+
+func newTypedHandler(fn interface{}) *typedHandler {
+	v := reflect.ValueOf(fn)
+	t := v.Type()
+	if t.Kind() != reflect.Func ||
+		t.NumIn() != 2 || t.In(0) != contextType ||
+		t.In(1).Kind() != reflect.Ptr || t.In(1).Elem().Kind() != reflect.Struct ||
+		t.NumOut() != 2 || t.Out(1) != errorType {
+		panic(fmt.Sprintf("seng: typed handler must be func(*seng.Context, *T) (*R, error), got %s", t))
+	}
+	out := t.Out(0)
+	for out.Kind() == reflect.Ptr {
+		out = out.Elem()
+	}
+	return &typedHandler{
+		fn:  v,
+		in:  t.In(1).Elem(),
+		out: out,
+	}
+}
+
+// handle implements Handler
+func (h *typedHandler) handle(c *Context) error {
+	in := reflect.New(h.in)
+	if err := c.Bind(in.Interface()); err != nil {
+		return NewError(http.StatusBadRequest, err.Error())
+	}
+	if err := c.Validate(in.Interface()); err != nil {
+		var fieldErrs ValidationErrors
+		if errors.As(err, &fieldErrs) {
+			return validationError(fieldErrs)
+		}
+		// invalid rules of T
+		return err
+	}
+	results := h.fn.Call([]reflect.Value{reflect.ValueOf(c), in})
+	if err, _ := results[1].Interface().(error); err != nil {
+		return err
+	}
+	out := results[0]
+	if isNilValue(out) {
+		return c.NoContent()
+	}
+	return c.OK(out.Interface())
+}
+
+// isNilValue reports whether v is nil, without panicking for non-nillable kinds
+func isNilValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface, reflect.Chan, reflect.Func:
+		return v.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+package seng
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestTyped(t *testing.T) {
+	type pet struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+	}
+	type updatePet struct {
+		ID   int64  `param:"id"`
+		Name string `json:"name" validate:"required"`
+	}
+	e := New(Config{ErrorHandler: ProblemErrorHandler})
+	e.Typed(http.MethodPut, "/pets/:id", func(c *Context, in *updatePet) (*pet, error) {
+		if in.Name == "ghost" {
+			return nil, nil
+		}
+		return &pet{ID: in.ID, Name: in.Name}, nil
+	})
+
+	tests := []struct {
+		name     string
+		target   string
+		body     string
+		wantCode int
+		want     pet
+	}{
+		{"ok", "/pets/1", `{"name":"rex"}`, http.StatusOK, pet{ID: 1, Name: "rex"}},
+		{"body id is ignored", "/pets/1", `{"id":999,"name":"rex"}`, http.StatusOK, pet{ID: 1, Name: "rex"}},
+		{"nil result", "/pets/1", `{"name":"ghost"}`, http.StatusNoContent, pet{}},
+		{"bad json", "/pets/1", `{"name":`, http.StatusBadRequest, pet{}},
+		{"bad param", "/pets/x", `{"name":"rex"}`, http.StatusBadRequest, pet{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(t, e, http.MethodPut, tt.target, tt.body,
+				HeaderContentType, ContentTypeJson, HeaderAccept, ContentTypeJson)
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d, body %q", w.Code, tt.wantCode, w.Body)
+			}
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+			var got pet
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("result = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTypedValidationErrors(t *testing.T) {
+	type newPet struct {
+		Name string `json:"name" validate:"required"`
+		Age  int    `json:"age" validate:"min=1"`
+	}
+	e := New(Config{ErrorHandler: ProblemErrorHandler})
+	e.Typed(http.MethodPost, "/pets", func(c *Context, in *newPet) (*newPet, error) {
+		return in, nil
+	})
+	w := serve(t, e, http.MethodPost, "/pets", `{}`, HeaderContentType, ContentTypeJson)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", w.Code)
+	}
+	var problem struct {
+		Status int          `json:"status"`
+		Errors []FieldError `json:"errors"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+		t.Fatal(err)
+	}
+	if len(problem.Errors) != 2 || problem.Errors[0].Field != "name" || problem.Errors[1].Field != "age" {
+		t.Errorf("errors = %+v, want name and age", problem.Errors)
+	}
+	if problem.Errors[0].Rule != ValidateRequired || problem.Errors[0].Message == "" {
+		t.Errorf("name error = %+v", problem.Errors[0])
+	}
+}
+
+func TestTypedSignature(t *testing.T) {
+	tests := []interface{}{
+		func(c *Context) error { return nil },
+		func(c *Context, in struct{}) (*struct{}, error) { return nil, nil },
+		func(c *Context, in *int) (*struct{}, error) { return nil, nil },
+		func(c *Context, in *struct{}) *struct{} { return nil },
+	}
+	for _, fn := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Typed(%T) did not panic", fn)
+				}
+			}()
+			Typed(fn)
+		}()
+	}
+}
+
+func TestTypedInvalidRules(t *testing.T) {
+	type request struct {
+		Level int `json:"level" validate:"oneof=low high"`
+	}
+	e := New()
+	e.Typed(http.MethodPost, "/", func(c *Context, in *request) (*request, error) {
+		return in, nil
+	})
+	w := serve(t, e, http.MethodPost, "/", `{"level":1}`, HeaderContentType, ContentTypeJson)
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", w.Code)
+	}
+}
@@ -2,64 +2,605 @@ package seng
 
 import (
 	"errors"
+	"fmt"
+	"net/http"
 	"reflect"
+	"strconv"
 	"strings"
+	"sync"
 )
 
+// Validator validates structs by their `validate` tags.
+// The zero value is ready to use with the built-in rules.
 type Validator struct {
+	mutex sync.RWMutex
+	// custom rules
+	rules map[string]ValidationFunc
+	// custom messages, locale -> rule -> message
+	messages map[string]map[string]string
+	// compiled rules, reflect.Type -> *structRules
+	cache sync.Map
 }
 
 const (
 	ValidateParams   = "validate"
 	SplitMultiParams = "|"
 	SplitTagMessage  = "#"
+	SplitRuleParam   = "="
+	// LabelTag display name of a field in messages
+	LabelTag = "label"
 )
 
 const (
-	ValidateRequired = "required"
+	ValidateRequired  = "required"
+	ValidateOmitEmpty = "omitempty"
+	ValidateDive      = "dive"
+	// ValidateStruct is the rule of errors returned by Validatable
+	ValidateStruct = "struct"
+	// ValidateInvalid is the message key of rules without a message
+	ValidateInvalid = "invalid"
 )
 
+// FieldLevel is the field passed to a ValidationFunc
+type FieldLevel struct {
+	// Field value of the field, pointers dereferenced
+	Field reflect.Value
+	// Parent the struct holding the field, used by cross-field rules
+	Parent reflect.Value
+	// Param of the rule, min=1 -> 1
+	Param string
+	// Path json path of the field, items[2].price
+	Path string
+}
+
+// ValidationFunc reports whether the field satisfies the rule
+type ValidationFunc func(fl FieldLevel) bool
+
+// Validatable is implemented by structs with struct-level validation,
+// Validate is called after all the fields of the struct are valid.
+// The returned error may be a *FieldError or ValidationErrors, whose
+// fields are relative to the struct.
+type Validatable interface {
+	Validate() error
+}
+
+var validatableType = reflect.TypeOf((*Validatable)(nil)).Elem()
+
+// FieldError describes a failed validation rule
+type FieldError struct {
+	// Field is the json path of the field, items[2].price
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// Error implements error interface
+func (e *FieldError) Error() string {
+	return e.Message
+}
+
+// ValidationErrors every failed field of a validation
+type ValidationErrors []*FieldError
+
+// Error implements error interface
+func (e ValidationErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, fieldErr := range e {
+		messages = append(messages, fieldErr.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// validationError returns a 400 Bad Request wrapping errs, which are the
+// "errors" member of the problem details
+func validationError(errs ValidationErrors) *Error {
+	return NewError(http.StatusBadRequest, errs.Error()).WithInternal(errs).With("errors", errs)
+}
+
+// defaultValidator validates for contexts without engine, see NewContext
+var defaultValidator = new(Validator)
+
+// Validate validates data with messages in the locale of the request, see Context.Locales
 func (c *Context) Validate(data interface{}) error {
-	validator := c.engine.validatorPool.Get().(*Validator)
-	defer c.engine.validatorPool.Put(validator)
+	validator := defaultValidator
+	if c.engine != nil {
+		validator = c.engine.validator
+	}
+	return validator.ValidateLocale(data, c.Locales()...)
+}
 
-	return validator.Validate(data)
+// RegisterValidation register a custom rule, message is the default message
+// in DefaultLocale with {field} and {param} placeholders.
+// It returns an error for names which cannot be used in a validate tag.
+// v.RegisterValidation("sku", func(fl seng.FieldLevel) bool {...}, "{field} is not a known sku")
+func (s *Validator) RegisterValidation(tag string, fn ValidationFunc, message ...string) error {
+	if tag == "" || strings.ContainsAny(tag, SplitMultiParams+SplitTagMessage+SplitRuleParam+" \t") {
+		return fmt.Errorf("seng: invalid validation rule name %q", tag)
+	}
+	if tag == ValidateOmitEmpty || tag == ValidateDive {
+		return fmt.Errorf("seng: validation rule %q is reserved", tag)
+	}
+	if fn == nil {
+		return fmt.Errorf("seng: validation rule %q has no function", tag)
+	}
+	s.mutex.Lock()
+	if s.rules == nil {
+		s.rules = make(map[string]ValidationFunc)
+	}
+	s.rules[tag] = fn
+	s.mutex.Unlock()
+	// compiled rules hold the previous function
+	s.cache.Range(func(key, _ interface{}) bool {
+		s.cache.Delete(key)
+		return true
+	})
+	if len(message) > 0 {
+		s.RegisterMessages(DefaultLocale, map[string]string{tag: message[0]})
+	}
+	return nil
+}
+
+// RegisterMessages add or override the messages of locale, keyed by rule.
+// Messages may use the {field} and {param} placeholders, {field} is the
+// `label` tag of the field or its json path.
+// v.RegisterMessages("fr", map[string]string{"required": "{field} est obligatoire"})
+func (s *Validator) RegisterMessages(locale string, messages map[string]string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	locale = normalizeLocale(locale)
+	if s.messages == nil {
+		s.messages = make(map[string]map[string]string)
+	}
+	if s.messages[locale] == nil {
+		s.messages[locale] = make(map[string]string)
+	}
+	for rule, message := range messages {
+		s.messages[locale][rule] = message
+	}
+}
+
+// lookupRule returns the custom or built-in rule
+func (s *Validator) lookupRule(tag string) (ValidationFunc, bool) {
+	s.mutex.RLock()
+	fn, ok := s.rules[tag]
+	s.mutex.RUnlock()
+	if ok {
+		return fn, true
+	}
+	fn, ok = validateRules[tag]
+	return fn, ok
 }
 
+// Validate validates the struct tags of data, which may be a struct, a pointer
+// to a struct or a slice of them. Nested structs are validated recursively, and
+// rules after `dive` are applied to the elements of slices and maps.
+// The returned error is a ValidationErrors holding every failed field,
+// messages are in DefaultLocale.
 func (s *Validator) Validate(data interface{}) error {
-	d := reflect.TypeOf(data)
-	v := reflect.ValueOf(data)
-	fieldNum := d.NumField()
-	for i := 0; i < fieldNum; i++ {
-		field := d.Field(i)
-		tags := field.Tag
-		validateParam, ok := tags.Lookup(ValidateParams)
-		if !ok {
+	return s.ValidateLocale(data)
+}
+
+// ValidateLocale is like Validate, with messages in the first of locales
+// which has a catalog, see RegisterMessages.
+// The rules of a struct type are compiled by its first validation, unknown
+// rules and invalid params are returned as an error instead of
+// ValidationErrors.
+// v.ValidateLocale(req, "zh-CN", "en")
+func (s *Validator) ValidateLocale(data interface{}, locales ...string) error {
+	value := indirect(reflect.ValueOf(data))
+	if !value.IsValid() || value.Kind() == reflect.Ptr {
+		return errors.New("seng: cannot validate nil")
+	}
+	state := &validation{
+		Validator: s,
+		locale:    s.matchLocale(locales),
+	}
+	state.validateValue(value, "")
+	if state.err != nil {
+		return state.err
+	}
+	if len(state.errs) > 0 {
+		return state.errs
+	}
+	return nil
+}
+
+// validation is the state of a single Validate call
+type validation struct {
+	*Validator
+	// locale of the messages
+	locale string
+	errs   ValidationErrors
+	// err invalid rules of a struct
+	err error
+}
+
+// validateValue recurse into structs, slices and maps
+func (s *validation) validateValue(value reflect.Value, path string) {
+	value = indirect(value)
+	switch value.Kind() {
+	case reflect.Struct:
+		s.validateStruct(value, path)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			s.validateValue(value.Index(i), indexPath(path, i))
+		}
+	case reflect.Map:
+		iter := value.MapRange()
+		for iter.Next() {
+			s.validateValue(iter.Value(), keyPath(path, iter.Key()))
+		}
+	}
+}
+
+func (s *validation) validateStruct(value reflect.Value, path string) {
+	rules := s.structRules(value.Type())
+	if rules.err != nil {
+		s.err = rules.err
+		return
+	}
+	failed := len(s.errs)
+	for _, field := range rules.fields {
+		fieldPath := path
+		if !field.anonymous {
+			fieldPath = joinPath(path, field.name)
+		}
+		s.validateField(value, value.Field(field.index), fieldPath, field.label, field.tags)
+	}
+	// struct-level validation only runs on valid fields
+	if len(s.errs) == failed {
+		s.validateStructLevel(value, path)
+	}
+}
+
+// validateStructLevel calls Validatable
+func (s *validation) validateStructLevel(value reflect.Value, path string) {
+	var validatable Validatable
+	switch {
+	case value.Type().Implements(validatableType):
+		validatable = value.Interface().(Validatable)
+	case reflect.PtrTo(value.Type()).Implements(validatableType):
+		if !value.CanAddr() {
+			// copy to call pointer receivers
+			addressable := reflect.New(value.Type()).Elem()
+			addressable.Set(value)
+			value = addressable
+		}
+		validatable = value.Addr().Interface().(Validatable)
+	default:
+		return
+	}
+	err := validatable.Validate()
+	if err == nil {
+		return
+	}
+	var fieldErrs ValidationErrors
+	var fieldErr *FieldError
+	switch {
+	case errors.As(err, &fieldErrs):
+	case errors.As(err, &fieldErr):
+		fieldErrs = ValidationErrors{fieldErr}
+	default:
+		s.errs = append(s.errs, &FieldError{
+			Field:   path,
+			Rule:    ValidateStruct,
+			Message: err.Error(),
+		})
+		return
+	}
+	for _, fieldErr := range fieldErrs {
+		relative := *fieldErr
+		relative.Field = joinPath(path, fieldErr.Field)
+		s.errs = append(s.errs, &relative)
+	}
+}
+
+// validateField applies tags to value, the tags after dive to its elements
+func (s *validation) validateField(parent reflect.Value, value reflect.Value, path string, label string, tags []validateTag) {
+	rules, elemRules, dive := splitDive(tags)
+	if fieldErr := s.validate(parent, value, path, label, rules); fieldErr != nil {
+		s.errs = append(s.errs, fieldErr)
+		return
+	}
+	value = indirect(value)
+	if !dive {
+		s.validateValue(value, path)
+		return
+	}
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			s.validateField(parent, value.Index(i), indexPath(path, i), label, elemRules)
+		}
+	case reflect.Map:
+		iter := value.MapRange()
+		for iter.Next() {
+			s.validateField(parent, iter.Value(), keyPath(path, iter.Key()), label, elemRules)
+		}
+	default:
+		// nil, or an interface holding neither slice nor map
+	}
+}
+
+// validate checks value against tags,
+// and returns the error of the first failed rule
+func (s *validation) validate(parent reflect.Value, value reflect.Value, path string, label string, tags []validateTag) *FieldError {
+	// a non-nil pointer is present even if it points to a zero value
+	present := value.Kind() == reflect.Ptr && !value.IsNil()
+	// pointers are validated by the value they point to
+	value = indirect(value)
+	for _, tag := range tags {
+		if tag.rule == ValidateOmitEmpty {
+			if !present && isEmptyValue(value) {
+				return nil
+			}
+			continue
+		}
+		if tag.rule == ValidateRequired && present {
 			continue
 		}
-		success, msg := validate(v.Field(i).String(), validateParam)
-		if !success {
-			return errors.New(msg)
+		fl := FieldLevel{
+			Field:  value,
+			Parent: parent,
+			Param:  tag.param,
+			Path:   path,
+		}
+		if !tag.fn(fl) {
+			name := label
+			if name == "" {
+				name = path
+			}
+			return &FieldError{
+				Field:   path,
+				Rule:    tag.rule,
+				Param:   tag.param,
+				Message: s.message(name, tag),
+			}
 		}
 	}
 	return nil
 }
 
+// message returns the #message of the tag, else the message of its rule in
+// the catalog, with {field} and {param} replaced
+func (s *validation) message(name string, tag validateTag) string {
+	format, ok := tag.message, tag.message != ""
+	if !ok {
+		format, ok = s.lookupMessage(s.locale, tag.rule)
+	}
+	if !ok {
+		format, ok = s.lookupMessage(DefaultLocale, tag.rule)
+	}
+	if !ok {
+		format, ok = s.lookupMessage(s.locale, ValidateInvalid)
+	}
+	if !ok {
+		format, _ = s.lookupMessage(DefaultLocale, ValidateInvalid)
+	}
+	return strings.NewReplacer("{field}", name, "{param}", tag.param).Replace(format)
+}
+
+// structRules compiled validate tags of a struct type
+type structRules struct {
+	fields []fieldRules
+	// err the first invalid rule
+	err error
+}
+
+// fieldRules parsed validate tag of a field
+type fieldRules struct {
+	index     int
+	name      string
+	label     string
+	anonymous bool
+	tags      []validateTag
+}
+
+// structRules returns the compiled rules of t, compiling them once per type
+func (s *Validator) structRules(t reflect.Type) *structRules {
+	if rules, ok := s.cache.Load(t); ok {
+		return rules.(*structRules)
+	}
+	return s.compileStruct(t, make(map[reflect.Type]bool))
+}
+
+// compileStruct compiles the rules of t and of the structs of its fields, so
+// that the first validation of t reports their invalid rules. visiting holds
+// the types being compiled, for recursive types.
+func (s *Validator) compileStruct(t reflect.Type, visiting map[reflect.Type]bool) *structRules {
+	visiting[t] = true
+	rules := &structRules{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		// unexported
+		if field.PkgPath != "" {
+			continue
+		}
+		validateParam, _ := field.Tag.Lookup(ValidateParams)
+		tags, err := s.compileTags(t, field.Type, parseTags(validateParam))
+		if err != nil {
+			rules.err = fmt.Errorf("seng: validate %s.%s: %v", t, field.Name, err)
+			break
+		}
+		rules.fields = append(rules.fields, fieldRules{
+			index:     i,
+			name:      fieldName(field),
+			label:     field.Tag.Get(LabelTag),
+			anonymous: field.Anonymous,
+			tags:      tags,
+		})
+		if nested := structType(field.Type); nested != nil && !visiting[nested] {
+			nestedRules, ok := s.cache.Load(nested)
+			if !ok {
+				nestedRules = s.compileStruct(nested, visiting)
+			}
+			if err := nestedRules.(*structRules).err; err != nil {
+				rules.err = err
+				break
+			}
+		}
+	}
+	actual, _ := s.cache.LoadOrStore(t, rules)
+	return actual.(*structRules)
+}
+
+// compileTags resolves the rules of tags and checks their params against
+// field, the type of the field or of its elements after dive, and against
+// parent, the struct holding the field
+func (s *Validator) compileTags(parent, field reflect.Type, tags []validateTag) ([]validateTag, error) {
+	field = indirectType(field)
+	for i := range tags {
+		tag := &tags[i]
+		switch tag.rule {
+		case ValidateOmitEmpty:
+			continue
+		case ValidateDive:
+			switch field.Kind() {
+			case reflect.Slice, reflect.Array, reflect.Map:
+				field = indirectType(field.Elem())
+			case reflect.Interface:
+			default:
+				return nil, fmt.Errorf("dive on %s is not supported", field)
+			}
+			continue
+		}
+		fn, ok := s.lookupRule(tag.rule)
+		if !ok {
+			return nil, fmt.Errorf("unknown validation rule %q", tag.rule)
+		}
+		tag.fn = fn
+		if s.isCustomRule(tag.rule) {
+			continue
+		}
+		if check, ok := ruleChecks[tag.rule]; ok {
+			if err := check(field, tag.param); err != nil {
+				return nil, fmt.Errorf("rule %q: %v", tag.rule, err)
+			}
+		}
+		if check, ok := crossFieldChecks[tag.rule]; ok {
+			if err := check(parent, field, tag.param); err != nil {
+				return nil, fmt.Errorf("rule %q: %v", tag.rule, err)
+			}
+		}
+	}
+	return tags, nil
+}
+
+// isCustomRule reports whether rule was registered with RegisterValidation
+func (s *Validator) isCustomRule(rule string) bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	_, ok := s.rules[rule]
+	return ok
+}
+
+// indirectType strips the pointers of t
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// structType returns the struct type of the field, its elements or values
+func structType(t reflect.Type) reflect.Type {
+	for {
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+			t = t.Elem()
+		case reflect.Struct:
+			return t
+		default:
+			return nil
+		}
+	}
+}
+
+// splitDive splits tags at the first dive
+func splitDive(tags []validateTag) (rules []validateTag, elemRules []validateTag, dive bool) {
+	for i, tag := range tags {
+		if tag.rule == ValidateDive {
+			return tags[:i], tags[i+1:], true
+		}
+	}
+	return tags, nil, false
+}
+
+// indirect dereference pointers and interfaces until a nil or a value
+func indirect(value reflect.Value) reflect.Value {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return value
+		}
+		value = value.Elem()
+	}
+	return value
+}
+
+// joinPath items + price -> items.price
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	if name == "" {
+		return path
+	}
+	return path + "." + name
+}
+
+// indexPath items + 2 -> items[2]
+func indexPath(path string, i int) string {
+	return path + "[" + strconv.Itoa(i) + "]"
+}
+
+// keyPath attrs + color -> attrs[color]
+func keyPath(path string, key reflect.Value) string {
+	return path + "[" + fmt.Sprint(key.Interface()) + "]"
+}
+
+// validateTag is a single rule of a validate tag
+// required#message -> {rule: required, message: message}
+// min=1#message -> {rule: min, param: 1, message: message}
+type validateTag struct {
+	rule    string
+	param   string
+	message string
+	// fn the resolved rule
+	fn ValidationFunc
+}
+
 func splitParams(tags string) []string {
 	return strings.Split(tags, SplitMultiParams)
 }
 
-func validate(data, tags string) (bool, string) {
+// parseTags parse the validate tag of a field
+func parseTags(tags string) []validateTag {
 	params := splitParams(tags)
+	results := make([]validateTag, 0, len(params))
 	for _, param := range params {
-		tagAndMsg := strings.Split(param, SplitTagMessage)
-		// TODO more validator
-		// required
-		if tagAndMsg[0] == ValidateRequired {
-			if data == "" {
-				return false, tagAndMsg[1]
-			}
+		if param == "" {
+			continue
+		}
+		var tag validateTag
+		tagAndMsg := strings.SplitN(param, SplitTagMessage, 2)
+		if len(tagAndMsg) == 2 {
+			tag.message = tagAndMsg[1]
 		}
+		ruleAndParam := strings.SplitN(tagAndMsg[0], SplitRuleParam, 2)
+		tag.rule = strings.TrimSpace(ruleAndParam[0])
+		if len(ruleAndParam) == 2 {
+			tag.param = ruleAndParam[1]
+		}
+		results = append(results, tag)
+	}
+	return results
+}
+
+// fieldName returns the json name of the field, or the field name
+func fieldName(field reflect.StructField) string {
+	if name := strings.Split(field.Tag.Get("json"), ",")[0]; name != "" && name != "-" {
+		return name
 	}
-	return true, ""
+	return field.Name
 }
@@ -0,0 +1,180 @@
+package seng
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateCrossField(t *testing.T) {
+	type booking struct {
+		Password string    `validate:"required"`
+		Confirm  string    `validate:"eqfield=Password"`
+		Start    time.Time `validate:"required"`
+		End      time.Time `validate:"gtfield=Start"`
+		Min      int
+		Max      *int   `validate:"omitempty|gtefield=Min"`
+		Kind     string `validate:"oneof=person company"`
+		VAT      string `validate:"required_if=Kind company|excluded_unless=Kind company"`
+		Phone    string
+		Email    string
+		Contact  string `validate:"required_with=Phone Email"`
+	}
+	now := time.Now()
+	ten := 10
+	valid := func() booking {
+		return booking{Password: "p", Confirm: "p", Start: now, End: now.Add(time.Hour), Min: 5, Max: &ten, Kind: "person"}
+	}
+	tests := []struct {
+		name   string
+		modify func(b *booking)
+		field  string
+		rule   string
+	}{
+		{"valid", func(b *booking) {}, "", ""},
+		{"eqfield", func(b *booking) { b.Confirm = "q" }, "Confirm", "eqfield"},
+		{"gtfield time", func(b *booking) { b.End = now }, "End", "gtfield"},
+		{"gtefield pointer", func(b *booking) { b.Min = 11 }, "Max", "gtefield"},
+		{"required_if", func(b *booking) { b.Kind = "company" }, "VAT", "required_if"},
+		{"required_if ok", func(b *booking) { b.Kind, b.VAT = "company", "FR1" }, "", ""},
+		{"excluded_unless", func(b *booking) { b.VAT = "FR1" }, "VAT", "excluded_unless"},
+		{"required_with", func(b *booking) { b.Email = "a@b.c" }, "Contact", "required_with"},
+		{"required_with ok", func(b *booking) { b.Phone, b.Contact = "1", "me" }, "", ""},
+	}
+	v := new(Validator)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := valid()
+			tt.modify(&b)
+			err := v.Validate(&b)
+			if tt.field == "" {
+				if err != nil {
+					t.Fatalf("Validate = %v", err)
+				}
+				return
+			}
+			var errs ValidationErrors
+			if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Field != tt.field || errs[0].Rule != tt.rule {
+				t.Errorf("Validate = %v, want %s %s", err, tt.field, tt.rule)
+			}
+		})
+	}
+}
+
+func TestValidateInvalidCrossField(t *testing.T) {
+	tests := []struct {
+		name string
+		data interface{}
+		want string
+	}{
+		{"unknown field", &struct {
+			A string `validate:"eqfield=B"`
+		}{}, `has no field "B"`},
+		{"incomparable", &struct {
+			A string `validate:"gtfield=B"`
+			B int
+		}{}, "cannot compare string with B int"},
+		{"odd pairs", &struct {
+			A string `validate:"required_if=B"`
+			B string
+		}{}, "expect field value pairs"},
+		{"bad value", &struct {
+			A string `validate:"required_if=B yes"`
+			B bool
+		}{}, `invalid param "yes"`},
+		{"required_with unknown", &struct {
+			A string `validate:"required_with=C"`
+		}{}, `has no field "C"`},
+	}
+	v := new(Validator)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.Validate(tt.data)
+			var errs ValidationErrors
+			if err == nil || errors.As(err, &errs) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterValidation(t *testing.T) {
+	type product struct {
+		SKU string `json:"sku" validate:"sku"`
+	}
+	v := new(Validator)
+	if err := v.Validate(&product{}); err == nil || !strings.Contains(err.Error(), `unknown validation rule "sku"`) {
+		t.Fatalf("Validate before registration = %v", err)
+	}
+	err := v.RegisterValidation("sku", func(fl FieldLevel) bool {
+		return strings.HasPrefix(fl.Field.String(), "SKU-")
+	}, "{field} is not a sku")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		sku  string
+		want string
+	}{
+		{"SKU-1", ""},
+		{"1", "sku is not a sku"},
+	}
+	for _, tt := range tests {
+		err := v.Validate(&product{SKU: tt.sku})
+		if (err == nil && tt.want != "") || (err != nil && err.Error() != tt.want) {
+			t.Errorf("Validate(%q) = %v, want %q", tt.sku, err, tt.want)
+		}
+	}
+
+	for _, name := range []string{"", "a|b", "a=b", "a#b", "a b", ValidateOmitEmpty, ValidateDive} {
+		if err := v.RegisterValidation(name, func(FieldLevel) bool { return true }); err == nil {
+			t.Errorf("RegisterValidation(%q) = nil, want error", name)
+		}
+	}
+	if err := v.RegisterValidation("nil", nil); err == nil {
+		t.Error("RegisterValidation with nil function = nil, want error")
+	}
+}
+
+type booking struct {
+	Rooms  int `json:"rooms" validate:"min=1"`
+	Guests int `json:"guests"`
+}
+
+func (b *booking) Validate() error {
+	if b.Rooms > b.Guests {
+		return &FieldError{Field: "rooms", Rule: "rooms", Message: "too many rooms"}
+	}
+	return nil
+}
+
+func TestValidateStructLevel(t *testing.T) {
+	type trip struct {
+		Booking booking `json:"booking"`
+	}
+	tests := []struct {
+		name string
+		trip trip
+		want string
+	}{
+		{"valid", trip{booking{Rooms: 1, Guests: 2}}, ""},
+		{"struct level", trip{booking{Rooms: 3, Guests: 2}}, "booking.rooms"},
+		{"fields first", trip{booking{Rooms: 0, Guests: -1}}, "booking.rooms"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := new(Validator).Validate(tt.trip)
+			if tt.want == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			var errs ValidationErrors
+			if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Field != tt.want {
+				t.Errorf("Validate = %v, want %s", err, tt.want)
+			}
+		})
+	}
+}
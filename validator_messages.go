@@ -0,0 +1,126 @@
+package seng
+
+import "strings"
+
+// DefaultLocale locale of validation messages when the request has no match
+const DefaultLocale = "en"
+
+// validationMessages built-in message catalogs, locale -> rule -> message
+var validationMessages = map[string]map[string]string{
+	"en": {
+		ValidateRequired:  "{field} is required",
+		ValidateInvalid:   "{field} is invalid",
+		"min":             "{field} must be at least {param}",
+		"max":             "{field} must be at most {param}",
+		"len":             "{field} must be {param} in length",
+		"eq":              "{field} must be equal to {param}",
+		"ne":              "{field} must not be equal to {param}",
+		"gt":              "{field} must be greater than {param}",
+		"gte":             "{field} must be greater than or equal to {param}",
+		"lt":              "{field} must be less than {param}",
+		"lte":             "{field} must be less than or equal to {param}",
+		"oneof":           "{field} must be one of [{param}]",
+		"regexp":          "{field} must match {param}",
+		"email":           "{field} must be a valid email address",
+		"url":             "{field} must be a valid URL",
+		"uuid":            "{field} must be a valid UUID",
+		"ip":              "{field} must be a valid IP address",
+		"cidr":            "{field} must be a valid CIDR notation",
+		"datetime":        "{field} must be a datetime in the format {param}",
+		"alpha":           "{field} can only contain alphabetic characters",
+		"alphanum":        "{field} can only contain alphanumeric characters",
+		"numeric":         "{field} must be a valid numeric value",
+		"contains":        "{field} must contain {param}",
+		"startswith":      "{field} must start with {param}",
+		"eqfield":         "{field} must be equal to {param}",
+		"nefield":         "{field} must not be equal to {param}",
+		"gtfield":         "{field} must be greater than {param}",
+		"gtefield":        "{field} must be greater than or equal to {param}",
+		"ltfield":         "{field} must be less than {param}",
+		"ltefield":        "{field} must be less than or equal to {param}",
+		"required_if":     "{field} is required when {param}",
+		"required_with":   "{field} is required when {param} is present",
+		"excluded_unless": "{field} must be empty unless {param}",
+	},
+	"zh": {
+		ValidateRequired:  "{field}为必填字段",
+		ValidateInvalid:   "{field}无效",
+		"min":             "{field}不能小于{param}",
+		"max":             "{field}不能大于{param}",
+		"len":             "{field}长度必须为{param}",
+		"eq":              "{field}必须等于{param}",
+		"ne":              "{field}不能等于{param}",
+		"gt":              "{field}必须大于{param}",
+		"gte":             "{field}必须大于或等于{param}",
+		"lt":              "{field}必须小于{param}",
+		"lte":             "{field}必须小于或等于{param}",
+		"oneof":           "{field}必须是[{param}]中的一个",
+		"regexp":          "{field}必须匹配{param}",
+		"email":           "{field}必须是一个有效的邮箱",
+		"url":             "{field}必须是一个有效的URL",
+		"uuid":            "{field}必须是一个有效的UUID",
+		"ip":              "{field}必须是一个有效的IP地址",
+		"cidr":            "{field}必须是一个有效的CIDR",
+		"datetime":        "{field}的格式必须是{param}",
+		"alpha":           "{field}只能包含字母",
+		"alphanum":        "{field}只能包含字母和数字",
+		"numeric":         "{field}必须是一个有效的数值",
+		"contains":        "{field}必须包含{param}",
+		"startswith":      "{field}必须以{param}开头",
+		"eqfield":         "{field}必须等于{param}",
+		"nefield":         "{field}不能等于{param}",
+		"gtfield":         "{field}必须大于{param}",
+		"gtefield":        "{field}必须大于或等于{param}",
+		"ltfield":         "{field}必须小于{param}",
+		"ltefield":        "{field}必须小于或等于{param}",
+		"required_if":     "{field}在{param}时为必填字段",
+		"required_with":   "{field}在{param}存在时为必填字段",
+		"excluded_unless": "{field}在{param}以外必须为空",
+	},
+}
+
+// normalizeLocale zh_CN -> zh-cn
+func normalizeLocale(locale string) string {
+	return strings.ToLower(strings.Replace(strings.TrimSpace(locale), "_", "-", -1))
+}
+
+// hasCatalog reports whether locale has custom or built-in messages
+func (s *Validator) hasCatalog(locale string) bool {
+	if _, ok := validationMessages[locale]; ok {
+		return true
+	}
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	_, ok := s.messages[locale]
+	return ok
+}
+
+// matchLocale returns the first of locales with a catalog,
+// zh-CN matches zh-cn then zh
+func (s *Validator) matchLocale(locales []string) string {
+	for _, locale := range locales {
+		locale = normalizeLocale(locale)
+		if locale == "" {
+			continue
+		}
+		if s.hasCatalog(locale) {
+			return locale
+		}
+		if i := strings.IndexByte(locale, '-'); i > 0 && s.hasCatalog(locale[:i]) {
+			return locale[:i]
+		}
+	}
+	return DefaultLocale
+}
+
+// lookupMessage returns the custom or built-in message of rule in locale
+func (s *Validator) lookupMessage(locale string, rule string) (string, bool) {
+	s.mutex.RLock()
+	message, ok := s.messages[locale][rule]
+	s.mutex.RUnlock()
+	if ok {
+		return message, true
+	}
+	message, ok = validationMessages[locale][rule]
+	return message, ok
+}
@@ -0,0 +1,71 @@
+package seng
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestValidateMessages(t *testing.T) {
+	type signup struct {
+		Name  string `json:"name" validate:"required"`
+		Age   int    `json:"age" label:"Your age" validate:"min=18"`
+		Email string `json:"email" validate:"required#give us an email"`
+		Nick  string `json:"nick" label:"Nickname" validate:"omitempty|min=3#{field} needs {param} characters"`
+		Code  string `json:"code" validate:"omitempty|sku"`
+	}
+	v := new(Validator)
+	if err := v.RegisterValidation("sku", func(fl FieldLevel) bool { return false }); err != nil {
+		t.Fatal(err)
+	}
+	v.RegisterMessages("fr", map[string]string{ValidateRequired: "{field} est obligatoire"})
+	tests := []struct {
+		name    string
+		locales []string
+		data    signup
+		want    string
+	}{
+		{"default", nil, signup{Age: 18, Email: "e"}, "name is required"},
+		{"label and param", nil, signup{Name: "n", Age: 1, Email: "e"}, "Your age must be at least 18"},
+		{"tag message", nil, signup{Name: "n", Age: 18}, "give us an email"},
+		{"tag message with field and param", nil, signup{Name: "n", Age: 18, Email: "e", Nick: "ab"}, "Nickname needs 3 characters"},
+		{"built-in catalog", []string{"zh-CN"}, signup{Age: 18, Email: "e"}, "name为必填字段"},
+		{"custom catalog", []string{"fr-FR", "en"}, signup{Age: 18, Email: "e"}, "name est obligatoire"},
+		{"falls back to default locale", []string{"fr"}, signup{Name: "n", Age: 1, Email: "e"}, "Your age must be at least 18"},
+		{"unknown locale", []string{"de"}, signup{Age: 18, Email: "e"}, "name is required"},
+		{"rule without message", nil, signup{Name: "n", Age: 18, Email: "e", Code: "x"}, "code is invalid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.ValidateLocale(&tt.data, tt.locales...)
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("ValidateLocale = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestContextValidateLocale(t *testing.T) {
+	type form struct {
+		Name string `json:"name" validate:"required"`
+	}
+	tests := []struct {
+		acceptLanguage string
+		want           string
+	}{
+		{"zh-CN,zh;q=0.9", "name为必填字段"},
+		{"de, en;q=0.5", "name is required"},
+		{"", "name is required"},
+	}
+	for _, tt := range tests {
+		e := New()
+		var got string
+		e.POST("/", func(c *Context) error {
+			got = c.Validate(&form{}).Error()
+			return nil
+		})
+		serve(t, e, http.MethodPost, "/", "", HeaderAcceptLanguage, tt.acceptLanguage)
+		if got != tt.want {
+			t.Errorf("Accept-Language %q: %q, want %q", tt.acceptLanguage, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,547 @@
+package seng
+
+import (
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// valueRule adapts a rule which only needs the value of the field
+func valueRule(fn func(value reflect.Value, param string) bool) ValidationFunc {
+	return func(fl FieldLevel) bool {
+		return fn(fl.Field, fl.Param)
+	}
+}
+
+// validateRules built-in rules
+// min=1 max=10 len=5  -> length of strings, slices and maps, value of numbers
+// eq=a ne=a           -> value of strings, bools and numbers, length of slices and maps
+// gt gte lt lte       -> like min and max
+// oneof=a b c         -> space separated values
+var validateRules = map[string]ValidationFunc{
+	// structs are always present
+	ValidateRequired: valueRule(func(value reflect.Value, _ string) bool {
+		return value.Kind() == reflect.Struct || !isEmptyValue(value)
+	}),
+	"min": valueRule(func(value reflect.Value, param string) bool {
+		result, ok := compareSize(value, param)
+		return ok && result >= 0
+	}),
+	"max": valueRule(func(value reflect.Value, param string) bool {
+		result, ok := compareSize(value, param)
+		return ok && result <= 0
+	}),
+	"len": valueRule(func(value reflect.Value, param string) bool {
+		result, ok := compareSize(value, param)
+		return ok && result == 0
+	}),
+	"gt": valueRule(func(value reflect.Value, param string) bool {
+		result, ok := compareSize(value, param)
+		return ok && result > 0
+	}),
+	"gte": valueRule(func(value reflect.Value, param string) bool {
+		result, ok := compareSize(value, param)
+		return ok && result >= 0
+	}),
+	"lt": valueRule(func(value reflect.Value, param string) bool {
+		result, ok := compareSize(value, param)
+		return ok && result < 0
+	}),
+	"lte": valueRule(func(value reflect.Value, param string) bool {
+		result, ok := compareSize(value, param)
+		return ok && result <= 0
+	}),
+	"eq": valueRule(equalParam),
+	"ne": valueRule(func(value reflect.Value, param string) bool {
+		return !equalParam(value, param)
+	}),
+	"oneof": valueRule(func(value reflect.Value, param string) bool {
+		for _, option := range strings.Fields(param) {
+			if equalParam(value, option) {
+				return true
+			}
+		}
+		return false
+	}),
+	// the pattern cannot contain | or #, which separate the rules and the
+	// message, register a custom rule for alternation
+	"regexp": stringRule(func(s string, param string) bool {
+		re, err := compileRegexp(param)
+		return err == nil && re.MatchString(s)
+	}),
+	"email": stringRule(func(s string, _ string) bool {
+		address, err := mail.ParseAddress(s)
+		return err == nil && address.Address == s
+	}),
+	"url": stringRule(func(s string, _ string) bool {
+		u, err := url.Parse(s)
+		return err == nil && u.Scheme != "" && u.Host != ""
+	}),
+	"uuid": stringRule(func(s string, _ string) bool {
+		return uuidRegexp.MatchString(s)
+	}),
+	"ip": stringRule(func(s string, _ string) bool {
+		return net.ParseIP(s) != nil
+	}),
+	"cidr": stringRule(func(s string, _ string) bool {
+		_, _, err := net.ParseCIDR(s)
+		return err == nil
+	}),
+	// datetime=2006-01-02, default layout is time.RFC3339
+	"datetime": stringRule(func(s string, param string) bool {
+		if param == "" {
+			param = time.RFC3339
+		}
+		_, err := time.Parse(param, s)
+		return err == nil
+	}),
+	"alpha": stringRule(func(s string, _ string) bool {
+		return alphaRegexp.MatchString(s)
+	}),
+	"alphanum": stringRule(func(s string, _ string) bool {
+		return alphaNumRegexp.MatchString(s)
+	}),
+	"numeric": stringRule(func(s string, _ string) bool {
+		return numericRegexp.MatchString(s)
+	}),
+	"contains":   stringRule(strings.Contains),
+	"startswith": stringRule(strings.HasPrefix),
+	// cross-field rules, param is the Go name of a field of the same struct
+	// eqfield=Password gtfield=StartDate
+	"eqfield": crossFieldRule(func(result int) bool {
+		return result == 0
+	}),
+	"nefield": crossFieldRule(func(result int) bool {
+		return result != 0
+	}),
+	"gtfield": crossFieldRule(func(result int) bool {
+		return result > 0
+	}),
+	"gtefield": crossFieldRule(func(result int) bool {
+		return result >= 0
+	}),
+	"ltfield": crossFieldRule(func(result int) bool {
+		return result < 0
+	}),
+	"ltefield": crossFieldRule(func(result int) bool {
+		return result <= 0
+	}),
+	// required_if=Status active Kind user -> required if Status is active and Kind is user
+	"required_if": func(fl FieldLevel) bool {
+		if !fieldsMatch(fl.Parent, fl.Param) {
+			return true
+		}
+		return !isEmptyValue(fl.Field)
+	},
+	// required_with=Phone Email -> required if Phone or Email is present
+	"required_with": func(fl FieldLevel) bool {
+		for _, name := range strings.Fields(fl.Param) {
+			if !isEmptyValue(indirect(fl.Parent.FieldByName(name))) {
+				return !isEmptyValue(fl.Field)
+			}
+		}
+		return true
+	},
+	// excluded_unless=Kind company -> must be empty unless Kind is company
+	"excluded_unless": func(fl FieldLevel) bool {
+		if fieldsMatch(fl.Parent, fl.Param) {
+			return true
+		}
+		return isEmptyValue(fl.Field)
+	},
+}
+
+var (
+	uuidRegexp     = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	alphaRegexp    = regexp.MustCompile(`^[a-zA-Z]+$`)
+	alphaNumRegexp = regexp.MustCompile(`^[a-zA-Z0-9]+$`)
+	numericRegexp  = regexp.MustCompile(`^[-+]?[0-9]+(?:\.[0-9]+)?$`)
+	// compiled regexp rules
+	regexpCache sync.Map
+)
+
+// compileRegexp compile the param of the regexp rule once
+func compileRegexp(expr string) (*regexp.Regexp, error) {
+	if re, ok := regexpCache.Load(expr); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	regexpCache.Store(expr, re)
+	return re, nil
+}
+
+// stringRule only applies fn to strings, other kinds fail
+func stringRule(fn func(s string, param string) bool) ValidationFunc {
+	return func(fl FieldLevel) bool {
+		if fl.Field.Kind() != reflect.String {
+			return false
+		}
+		return fn(fl.Field.String(), fl.Param)
+	}
+}
+
+// isEmptyValue reports whether value is the zero value of its type
+func isEmptyValue(value reflect.Value) bool {
+	if !value.IsValid() {
+		return true
+	}
+	return value.IsZero()
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// compareSize compares the size of value with param
+// strings, slices, arrays and maps compare their length, numbers their value.
+// Invalid params are reported when the rules are compiled, see checkSizeParam,
+// here they fail the rule.
+func compareSize(value reflect.Value, param string) (int, bool) {
+	switch value.Kind() {
+	case reflect.String:
+		i, err := strconv.ParseInt(param, 10, 64)
+		return compareInt(int64(utf8.RuneCountInString(value.String())), i), err == nil
+	case reflect.Slice, reflect.Array, reflect.Map:
+		i, err := strconv.ParseInt(param, 10, 64)
+		return compareInt(int64(value.Len()), i), err == nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if value.Type() == durationType {
+			d, err := time.ParseDuration(param)
+			return compareInt(value.Int(), int64(d)), err == nil
+		}
+		i, err := strconv.ParseInt(param, 10, 64)
+		return compareInt(value.Int(), i), err == nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u, err := strconv.ParseUint(param, 10, 64)
+		if err != nil {
+			return 0, false
+		}
+		switch {
+		case value.Uint() < u:
+			return -1, true
+		case value.Uint() > u:
+			return 1, true
+		}
+		return 0, true
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return 0, false
+		}
+		switch {
+		case value.Float() < f:
+			return -1, true
+		case value.Float() > f:
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// equalParam compares strings and bools by value, others like compareSize
+func equalParam(value reflect.Value, param string) bool {
+	switch value.Kind() {
+	case reflect.String:
+		return value.String() == param
+	case reflect.Bool:
+		b, err := strconv.ParseBool(param)
+		return err == nil && value.Bool() == b
+	}
+	result, ok := compareSize(value, param)
+	return ok && result == 0
+}
+
+// crossFieldRule compares the field with the field named by param
+func crossFieldRule(fn func(result int) bool) ValidationFunc {
+	return func(fl FieldLevel) bool {
+		result, ok := compareValues(fl.Field, indirect(fl.Parent.FieldByName(fl.Param)))
+		return ok && fn(result)
+	}
+}
+
+// fieldsMatch reports whether every "Field value" pair of param matches parent
+func fieldsMatch(parent reflect.Value, param string) bool {
+	pairs := strings.Fields(param)
+	if len(pairs)%2 != 0 {
+		return false
+	}
+	for i := 0; i < len(pairs); i += 2 {
+		field := indirect(parent.FieldByName(pairs[i]))
+		if !field.IsValid() || field.Kind() == reflect.Ptr || !equalParam(field, pairs[i+1]) {
+			return false
+		}
+	}
+	return true
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// compareValues compares two values of the same kind
+func compareValues(a, b reflect.Value) (int, bool) {
+	if !a.IsValid() || !b.IsValid() {
+		return 0, false
+	}
+	if a.Type() == timeType && b.Type() == timeType {
+		at, bt := a.Interface().(time.Time), b.Interface().(time.Time)
+		switch {
+		case at.Before(bt):
+			return -1, true
+		case at.After(bt):
+			return 1, true
+		}
+		return 0, true
+	}
+	switch a.Kind() {
+	case reflect.String:
+		if b.Kind() == reflect.String {
+			return strings.Compare(a.String(), b.String()), true
+		}
+	case reflect.Bool:
+		if b.Kind() == reflect.Bool && a.Bool() == b.Bool() {
+			return 0, true
+		}
+		return 1, b.Kind() == reflect.Bool
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		switch b.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return compareInt(a.Int(), b.Int()), true
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		switch b.Kind() {
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			switch {
+			case a.Uint() < b.Uint():
+				return -1, true
+			case a.Uint() > b.Uint():
+				return 1, true
+			}
+			return 0, true
+		}
+	case reflect.Float32, reflect.Float64:
+		switch b.Kind() {
+		case reflect.Float32, reflect.Float64:
+			switch {
+			case a.Float() < b.Float():
+				return -1, true
+			case a.Float() > b.Float():
+				return 1, true
+			}
+			return 0, true
+		}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		switch b.Kind() {
+		case reflect.Slice, reflect.Array, reflect.Map:
+			return compareInt(int64(a.Len()), int64(b.Len())), true
+		}
+	}
+	return 0, false
+}
+
+func compareInt(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// ruleChecks check the params of built-in rules against the type of the
+// field when the rules of a struct are compiled, field has no pointers
+var ruleChecks = map[string]func(field reflect.Type, param string) error{
+	"min":   checkSizeParam,
+	"max":   checkSizeParam,
+	"len":   checkSizeParam,
+	"gt":    checkSizeParam,
+	"gte":   checkSizeParam,
+	"lt":    checkSizeParam,
+	"lte":   checkSizeParam,
+	"eq":    checkEqualParam,
+	"ne":    checkEqualParam,
+	"oneof": checkOneOfParam,
+	"regexp": func(field reflect.Type, param string) error {
+		if err := checkString(field, param); err != nil {
+			return err
+		}
+		_, err := compileRegexp(param)
+		return err
+	},
+	"email":      checkString,
+	"url":        checkString,
+	"uuid":       checkString,
+	"ip":         checkString,
+	"cidr":       checkString,
+	"datetime":   checkString,
+	"alpha":      checkString,
+	"alphanum":   checkString,
+	"numeric":    checkString,
+	"contains":   checkString,
+	"startswith": checkString,
+}
+
+// checkSizeParam checks the param of compareSize
+func checkSizeParam(field reflect.Type, param string) error {
+	var err error
+	switch field.Kind() {
+	case reflect.Interface:
+		// checked by value
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		_, err = strconv.ParseInt(param, 10, 64)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if field == durationType {
+			_, err = time.ParseDuration(param)
+		} else {
+			_, err = strconv.ParseInt(param, 10, 64)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		_, err = strconv.ParseUint(param, 10, 64)
+	case reflect.Float32, reflect.Float64:
+		_, err = strconv.ParseFloat(param, 64)
+	default:
+		return fmt.Errorf("not supported on %s", field)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid param %q for %s", param, field)
+	}
+	return nil
+}
+
+// checkEqualParam checks the param of equalParam
+func checkEqualParam(field reflect.Type, param string) error {
+	switch field.Kind() {
+	case reflect.String:
+		return nil
+	case reflect.Bool:
+		if _, err := strconv.ParseBool(param); err != nil {
+			return fmt.Errorf("invalid param %q for %s", param, field)
+		}
+		return nil
+	}
+	return checkSizeParam(field, param)
+}
+
+// checkOneOfParam checks every option of oneof
+func checkOneOfParam(field reflect.Type, param string) error {
+	options := strings.Fields(param)
+	if len(options) == 0 {
+		return fmt.Errorf("no options")
+	}
+	for _, option := range options {
+		if err := checkEqualParam(field, option); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkString checks that the rule is used on a string field
+func checkString(field reflect.Type, _ string) error {
+	if field.Kind() != reflect.String && field.Kind() != reflect.Interface {
+		return fmt.Errorf("not supported on %s", field)
+	}
+	return nil
+}
+
+// crossFieldChecks check the params of built-in rules naming fields of the
+// struct holding the field
+var crossFieldChecks = map[string]func(parent, field reflect.Type, param string) error{
+	"eqfield":         checkCompareField,
+	"nefield":         checkCompareField,
+	"gtfield":         checkCompareField,
+	"gtefield":        checkCompareField,
+	"ltfield":         checkCompareField,
+	"ltefield":        checkCompareField,
+	"required_if":     checkFieldValues,
+	"excluded_unless": checkFieldValues,
+	"required_with": func(parent, _ reflect.Type, param string) error {
+		names := strings.Fields(param)
+		if len(names) == 0 {
+			return fmt.Errorf("no fields")
+		}
+		for _, name := range names {
+			if _, err := lookupField(parent, name); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+// lookupField returns the type of the field name of parent, pointers stripped
+func lookupField(parent reflect.Type, name string) (reflect.Type, error) {
+	field, ok := parent.FieldByName(name)
+	if !ok {
+		return nil, fmt.Errorf("%s has no field %q", parent, name)
+	}
+	return indirectType(field.Type), nil
+}
+
+// checkCompareField checks that the field named by param can be compared
+// with the field by compareValues
+func checkCompareField(parent, field reflect.Type, param string) error {
+	other, err := lookupField(parent, param)
+	if err != nil {
+		return err
+	}
+	if !comparableTypes(field, other) {
+		return fmt.Errorf("cannot compare %s with %s %s", field, param, other)
+	}
+	return nil
+}
+
+// checkFieldValues checks the "Field value" pairs of fieldsMatch
+func checkFieldValues(parent, _ reflect.Type, param string) error {
+	pairs := strings.Fields(param)
+	if len(pairs) == 0 || len(pairs)%2 != 0 {
+		return fmt.Errorf("invalid param %q, expect field value pairs", param)
+	}
+	for i := 0; i < len(pairs); i += 2 {
+		other, err := lookupField(parent, pairs[i])
+		if err != nil {
+			return err
+		}
+		if err := checkEqualParam(other, pairs[i+1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// comparableTypes reports whether compareValues compares values of a and b
+func comparableTypes(a, b reflect.Type) bool {
+	if a.Kind() == reflect.Interface || b.Kind() == reflect.Interface {
+		return true
+	}
+	if a == timeType || b == timeType {
+		return a == b
+	}
+	kindClass := func(kind reflect.Kind) int {
+		switch kind {
+		case reflect.String:
+			return 1
+		case reflect.Bool:
+			return 2
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return 3
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			return 4
+		case reflect.Float32, reflect.Float64:
+			return 5
+		case reflect.Slice, reflect.Array, reflect.Map:
+			return 6
+		}
+		return 0
+	}
+	return kindClass(a.Kind()) != 0 && kindClass(a.Kind()) == kindClass(b.Kind())
+}
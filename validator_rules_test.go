@@ -0,0 +1,175 @@
+package seng
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateRules(t *testing.T) {
+	type input struct {
+		Name     string        `validate:"min=2|max=5"`
+		Code     string        `validate:"omitempty|len=3"`
+		Age      int           `validate:"gte=18|lt=130"`
+		Score    float64       `validate:"gt=0|lte=10"`
+		Count    uint          `validate:"ne=0"`
+		Timeout  time.Duration `validate:"max=1m"`
+		Role     string        `validate:"oneof=admin user"`
+		Level    int           `validate:"oneof=1 2 3"`
+		Active   bool          `validate:"eq=true"`
+		Tags     []string      `validate:"max=2|dive|alpha"`
+		Email    string        `validate:"omitempty|email"`
+		Site     string        `validate:"omitempty|url"`
+		ID       string        `validate:"omitempty|uuid"`
+		IP       string        `validate:"omitempty|ip"`
+		Net      string        `validate:"omitempty|cidr"`
+		Day      string        `validate:"omitempty|datetime=2006-01-02"`
+		Slug     string        `validate:"omitempty|regexp=^[a-z-]+$"`
+		Number   string        `validate:"omitempty|numeric"`
+		Nick     string        `validate:"omitempty|alphanum|startswith=x|contains=y"`
+		Nickname *string       `validate:"required"`
+	}
+	nick := ""
+	valid := func() input {
+		return input{
+			Name: "bob", Age: 30, Score: 5, Count: 1, Timeout: time.Second,
+			Role: "user", Level: 2, Active: true, Tags: []string{"a"}, Nickname: &nick,
+		}
+	}
+	tests := []struct {
+		name   string
+		modify func(in *input)
+		field  string
+		rule   string
+	}{
+		{"valid", func(in *input) {}, "", ""},
+		{"min", func(in *input) { in.Name = "b" }, "Name", "min"},
+		{"max counts runes", func(in *input) { in.Name = "ééééé" }, "", ""},
+		{"max", func(in *input) { in.Name = "bobbyy" }, "Name", "max"},
+		{"omitempty skips", func(in *input) { in.Code = "" }, "", ""},
+		{"len", func(in *input) { in.Code = "ab" }, "Code", "len"},
+		{"gte", func(in *input) { in.Age = 17 }, "Age", "gte"},
+		{"lt", func(in *input) { in.Age = 130 }, "Age", "lt"},
+		{"gt float", func(in *input) { in.Score = 0 }, "Score", "gt"},
+		{"lte float", func(in *input) { in.Score = 10.5 }, "Score", "lte"},
+		{"ne uint", func(in *input) { in.Count = 0 }, "Count", "ne"},
+		{"duration", func(in *input) { in.Timeout = time.Hour }, "Timeout", "max"},
+		{"oneof string", func(in *input) { in.Role = "root" }, "Role", "oneof"},
+		{"oneof int", func(in *input) { in.Level = 4 }, "Level", "oneof"},
+		{"eq bool", func(in *input) { in.Active = false }, "Active", "eq"},
+		{"slice length", func(in *input) { in.Tags = []string{"a", "b", "c"} }, "Tags", "max"},
+		{"dive", func(in *input) { in.Tags = []string{"a", "1"} }, "Tags[1]", "alpha"},
+		{"email", func(in *input) { in.Email = "bob" }, "Email", "email"},
+		{"email with name", func(in *input) { in.Email = "Bob <bob@example.com>" }, "Email", "email"},
+		{"email ok", func(in *input) { in.Email = "bob@example.com" }, "", ""},
+		{"url", func(in *input) { in.Site = "example.com" }, "Site", "url"},
+		{"uuid", func(in *input) { in.ID = "123" }, "ID", "uuid"},
+		{"ip", func(in *input) { in.IP = "1.2.3" }, "IP", "ip"},
+		{"cidr", func(in *input) { in.Net = "10.0.0.0" }, "Net", "cidr"},
+		{"datetime", func(in *input) { in.Day = "01/02/2006" }, "Day", "datetime"},
+		{"regexp", func(in *input) { in.Slug = "A" }, "Slug", "regexp"},
+		{"numeric", func(in *input) { in.Number = "1.2.3" }, "Number", "numeric"},
+		{"numeric ok", func(in *input) { in.Number = "-1.5" }, "", ""},
+		{"startswith", func(in *input) { in.Nick = "ay" }, "Nick", "startswith"},
+		{"contains", func(in *input) { in.Nick = "xa" }, "Nick", "contains"},
+		{"required pointer to zero", func(in *input) {}, "", ""},
+		{"required nil pointer", func(in *input) { in.Nickname = nil }, "Nickname", "required"},
+	}
+	v := new(Validator)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := valid()
+			tt.modify(&in)
+			err := v.Validate(&in)
+			if tt.field == "" {
+				if err != nil {
+					t.Fatalf("Validate = %v, want nil", err)
+				}
+				return
+			}
+			var errs ValidationErrors
+			if !errors.As(err, &errs) {
+				t.Fatalf("Validate = %v, want ValidationErrors", err)
+			}
+			if len(errs) != 1 || errs[0].Field != tt.field || errs[0].Rule != tt.rule {
+				t.Errorf("errors = %+v, want %s %s", errs, tt.field, tt.rule)
+			}
+		})
+	}
+}
+
+func TestValidateInvalidRules(t *testing.T) {
+	tests := []struct {
+		name string
+		data interface{}
+		want string
+	}{
+		{"unknown rule", &struct {
+			A string `validate:"nope"`
+		}{}, `unknown validation rule "nope"`},
+		{"oneof on int", &struct {
+			A int `validate:"oneof=a b"`
+		}{}, `invalid param "a"`},
+		{"min on bool", &struct {
+			A bool `validate:"min=1"`
+		}{}, "not supported on bool"},
+		{"bad int param", &struct {
+			A string `validate:"max=ten"`
+		}{}, `invalid param "ten"`},
+		{"bad duration", &struct {
+			A time.Duration `validate:"min=5"`
+		}{}, `invalid param "5"`},
+		{"bad bool", &struct {
+			A bool `validate:"eq=yes"`
+		}{}, `invalid param "yes"`},
+		{"bad regexp", &struct {
+			A string `validate:"regexp=(["`
+		}{}, "missing closing"},
+		// | separates the rules, the pattern is ^(a
+		{"regexp alternation", &struct {
+			A string `validate:"regexp=^(a|b)$"`
+		}{}, "missing closing"},
+		{"email on int", &struct {
+			A int `validate:"email"`
+		}{}, "not supported on int"},
+		{"dive on string", &struct {
+			A string `validate:"dive|required"`
+		}{}, "dive on string"},
+		{"rule after dive", &struct {
+			A []int `validate:"dive|email"`
+		}{}, "not supported on int"},
+		{"nested struct", &struct {
+			B *struct {
+				C int `validate:"oneof=x"`
+			}
+		}{}, `invalid param "x"`},
+	}
+	v := new(Validator)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 2; i++ {
+				err := v.Validate(tt.data)
+				var errs ValidationErrors
+				if err == nil || errors.As(err, &errs) {
+					t.Fatalf("Validate = %v, want a rule error", err)
+				}
+				if !strings.Contains(err.Error(), tt.want) {
+					t.Errorf("Validate = %q, want %q", err, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateRecursiveType(t *testing.T) {
+	type node struct {
+		Name     string  `validate:"required"`
+		Children []*node `validate:"max=2"`
+	}
+	err := new(Validator).Validate(&node{Name: "a", Children: []*node{{}}})
+	var errs ValidationErrors
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Field != "Children[0].Name" {
+		t.Errorf("Validate = %v, want Children[0].Name required", err)
+	}
+}
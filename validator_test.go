@@ -0,0 +1,126 @@
+package seng
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestValidateNested(t *testing.T) {
+	type item struct {
+		SKU   string  `json:"sku" validate:"required"`
+		Price float64 `json:"price" validate:"gt=0"`
+	}
+	type address struct {
+		City string `json:"city" validate:"required"`
+	}
+	type Base struct {
+		ID int `json:"id" validate:"gt=0"`
+	}
+	type order struct {
+		Base
+		Items    []item             `json:"items" validate:"min=1"`
+		Shipping *address           `json:"shipping"`
+		Billing  address            `json:"billing"`
+		Attrs    map[string]string  `json:"attrs" validate:"dive|max=3"`
+		Extra    map[string]*item   `json:"extra"`
+		Codes    [][]string         `json:"codes" validate:"dive|dive|len=2"`
+		Notes    []*string          `json:"notes" validate:"dive|required"`
+		Meta     map[string]address `json:"-"`
+	}
+	valid := func() order {
+		return order{
+			Base:    Base{ID: 1},
+			Items:   []item{{SKU: "a", Price: 1}},
+			Billing: address{City: "Paris"},
+		}
+	}
+	tests := []struct {
+		name   string
+		modify func(o *order)
+		want   []string
+	}{
+		{"valid", func(o *order) {}, nil},
+		{"embedded", func(o *order) { o.ID = 0 }, []string{"id"}},
+		{"slice element", func(o *order) { o.Items = append(o.Items, item{Price: -1}) }, []string{"items[1].sku", "items[1].price"}},
+		{"slice rule before elements", func(o *order) { o.Items = nil }, []string{"items"}},
+		{"nil pointer", func(o *order) { o.Shipping = nil }, nil},
+		{"pointer", func(o *order) { o.Shipping = &address{} }, []string{"shipping.city"}},
+		{"struct", func(o *order) { o.Billing.City = "" }, []string{"billing.city"}},
+		{"map dive", func(o *order) { o.Attrs = map[string]string{"color": "purple"} }, []string{"attrs[color]"}},
+		{"map of structs", func(o *order) { o.Extra = map[string]*item{"gift": {SKU: "g"}} }, []string{"extra[gift].price"}},
+		{"nested dive", func(o *order) { o.Codes = [][]string{{"ab"}, {"ab", "c"}} }, []string{"codes[1][1]"}},
+		{"dive nil pointer", func(o *order) { o.Notes = []*string{nil} }, []string{"notes[0]"}},
+		{"field without json name", func(o *order) { o.Meta = map[string]address{"x": {}} }, []string{"Meta[x].city"}},
+	}
+	v := new(Validator)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := valid()
+			tt.modify(&o)
+			err := v.Validate(&o)
+			var got []string
+			var errs ValidationErrors
+			if errors.As(err, &errs) {
+				for _, fieldErr := range errs {
+					got = append(got, fieldErr.Field)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateTopLevel(t *testing.T) {
+	type item struct {
+		Name string `json:"name" validate:"required"`
+	}
+	tests := []struct {
+		name    string
+		data    interface{}
+		want    []string
+		wantErr bool
+	}{
+		{"struct", item{}, []string{"name"}, false},
+		{"slice", []item{{Name: "a"}, {}}, []string{"[1].name"}, false},
+		{"nil", nil, nil, true},
+		{"nil pointer", (*item)(nil), nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := new(Validator).Validate(tt.data)
+			var errs ValidationErrors
+			if !errors.As(err, &errs) {
+				if (err != nil) != tt.wantErr {
+					t.Fatalf("Validate = %v", err)
+				}
+				return
+			}
+			var got []string
+			for _, fieldErr := range errs {
+				got = append(got, fieldErr.Field)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContextValidateWithoutEngine(t *testing.T) {
+	type item struct {
+		Name string `json:"name" validate:"required"`
+	}
+	c := newTestContext()
+	var errs ValidationErrors
+	if err := c.Validate(item{}); !errors.As(err, &errs) || len(errs) != 1 {
+		t.Errorf("Validate = %v, want the required error", err)
+	}
+	if err := c.Validate(item{Name: "a"}); err != nil {
+		t.Errorf("Validate = %v", err)
+	}
+}
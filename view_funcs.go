@@ -0,0 +1,226 @@
+package seng
+
+import (
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// CSRFKey key of the CSRF token in Context.Values, returned by the csrf
+// template function
+const CSRFKey = "csrf"
+
+// ViewFunc returns a template function bound to the request, see Config.ViewFuncs
+type ViewFunc func(c *Context) interface{}
+
+// Translator translates key to locale for Context.Translate and the t
+// template function, args are the arguments of the message
+type Translator func(locale string, key string, args ...interface{}) string
+
+// defaultViewFuncs template functions bound to each request
+//
+//	{{url "user" "id" .ID}}   Engine.URL
+//	{{csrf}}                  the CSRFKey value
+//	{{t "hello" .Name}}       Context.Translate
+//	{{asset "css/app.css"}}   Engine.AssetURL
+//	{{local "user"}}          Context.ViewData
+//	{{flush}}                 see Context.RenderStream
+//	{{range flashes}}         Context.Flashes
+var defaultViewFuncs = map[string]ViewFunc{
+	"url": func(c *Context) interface{} {
+		return c.engine.URL
+	},
+	"csrf": func(c *Context) interface{} {
+		return func() string {
+			token, _ := c.Values[CSRFKey].(string)
+			return token
+		}
+	},
+	"t": func(c *Context) interface{} {
+		return c.Translate
+	},
+	"asset": func(c *Context) interface{} {
+		return c.engine.AssetURL
+	},
+	"local": func(c *Context) interface{} {
+		return func(key string) interface{} {
+			return c.ViewData()[key]
+		}
+	},
+	"flush": func(c *Context) interface{} {
+		return c.flushView
+	},
+	"flashes": func(c *Context) interface{} {
+		return c.Flashes
+	},
+}
+
+// flushView sends the page rendered so far by RenderStream, it does nothing
+// for buffered pages
+func (c *Context) flushView() string {
+	if flusher, ok := c.Writer.(http.Flusher); ok && c.streaming {
+		flusher.Flush()
+	}
+	return ""
+}
+
+// engineViewFuncs returns the view functions outside of requests
+func (e *Engine) engineViewFuncs() template.FuncMap {
+	funcs := e.viewFuncDeclarations()
+	funcs["url"] = e.URL
+	funcs["asset"] = e.AssetURL
+	funcs["local"] = func(key string) interface{} {
+		return e.viewData[key]
+	}
+	funcs["flush"] = func() string {
+		return ""
+	}
+	funcs["flashes"] = func() []Flash {
+		return nil
+	}
+	return funcs
+}
+
+// initViewFuncs merges the default and configured view functions, and
+// declares them in Config.Views so that templates using them parse
+func (e *Engine) initViewFuncs() {
+	e.viewFuncs = make(map[string]ViewFunc, len(defaultViewFuncs)+len(e.config.ViewFuncs))
+	for name, fn := range defaultViewFuncs {
+		e.viewFuncs[name] = fn
+	}
+	for name, fn := range e.config.ViewFuncs {
+		e.viewFuncs[name] = fn
+	}
+	if views, ok := e.config.Views.(*HTMLViews); ok {
+		views.Funcs(e.viewFuncDeclarations())
+	}
+}
+
+// viewFuncDeclarations returns placeholders of the view functions for
+// parsing, they fail when executed outside of a request
+func (e *Engine) viewFuncDeclarations() template.FuncMap {
+	funcs := make(template.FuncMap, len(e.viewFuncs))
+	for name := range e.viewFuncs {
+		name := name
+		funcs[name] = func(args ...interface{}) (interface{}, error) {
+			return nil, fmt.Errorf("seng: template function %s is bound to requests", name)
+		}
+	}
+	return funcs
+}
+
+// templateFuncs returns the functions LoadHTMLGlob parses templates with
+func (e *Engine) templateFuncs() template.FuncMap {
+	funcs := e.viewFuncDeclarations()
+	for name, fn := range e.funcMap {
+		funcs[name] = fn
+	}
+	return funcs
+}
+
+// viewFuncMap returns the view functions bound to c
+func (c *Context) viewFuncMap() template.FuncMap {
+	funcs := make(template.FuncMap, len(c.engine.viewFuncs))
+	for name, fn := range c.engine.viewFuncs {
+		funcs[name] = fn(c)
+	}
+	return funcs
+}
+
+// SetViewData sets global template data, merged into the data of every
+// rendered template. It must be called before serving requests.
+// e.SetViewData("site", "Seng")
+func (e *Engine) SetViewData(key string, value interface{}) {
+	if e.viewData == nil {
+		e.viewData = make(Map)
+	}
+	e.viewData[key] = value
+}
+
+// SetViewData sets template data of the request, e.g. the current user from
+// a middleware, merged into the data of the rendered template
+func (c *Context) SetViewData(key string, value interface{}) {
+	if c.viewData == nil {
+		c.viewData = make(Map)
+	}
+	c.viewData[key] = value
+}
+
+// ViewData returns the global and request template data
+func (c *Context) ViewData() Map {
+	data := make(Map, len(c.engine.viewData)+len(c.viewData))
+	for key, value := range c.engine.viewData {
+		data[key] = value
+	}
+	for key, value := range c.viewData {
+		data[key] = value
+	}
+	return data
+}
+
+// mergeViewData merges ViewData into map data of a handler
+func (c *Context) mergeViewData(data interface{}) interface{} {
+	return mergeViewData(c.ViewData(), data)
+}
+
+// mergeViewData merges viewData into map data, data wins. Other data is
+// returned unchanged, the view data is available with the local template
+// function.
+func mergeViewData(viewData Map, data interface{}) interface{} {
+	var values map[string]interface{}
+	switch data := data.(type) {
+	case nil:
+	case Map:
+		values = data
+	case map[string]interface{}:
+		values = data
+	default:
+		return data
+	}
+	merged := make(Map, len(viewData)+len(values))
+	for key, value := range viewData {
+		merged[key] = value
+	}
+	for key, value := range values {
+		merged[key] = value
+	}
+	return merged
+}
+
+// Translate translates key to the locale of the request with Config.Translator,
+// key is formatted with args without translator
+func (c *Context) Translate(key string, args ...interface{}) string {
+	if translator := c.engine.config.Translator; translator != nil {
+		return translator(c.Locale(), key, args...)
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(key, args...)
+	}
+	return key
+}
+
+// AssetURL returns the URL of the file name of Config.AssetsFS under
+// Config.AssetsPrefix with a version of its content for cache-busting,
+// "/static/css/app.css?v=1a2b3c4d". Versions are cached unless in debug mode.
+func (e *Engine) AssetURL(name string) (string, error) {
+	name = strings.TrimPrefix(name, "/")
+	if e.config.AssetsFS == nil {
+		return "", errors.New("seng: Config.AssetsFS is not set")
+	}
+	version, ok := e.assetVersions.Load(name)
+	if !ok || e.config.Debug {
+		data, err := fs.ReadFile(e.config.AssetsFS, name)
+		if err != nil {
+			return "", fmt.Errorf("seng: asset: %w", err)
+		}
+		version = strconv.FormatUint(uint64(crc32.ChecksumIEEE(data)), 16)
+		e.assetVersions.Store(name, version)
+	}
+	return path.Join(e.config.AssetsPrefix, name) + "?v=" + version.(string), nil
+}
@@ -0,0 +1,185 @@
+package seng
+
+import (
+	"fmt"
+	"hash/crc32"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestAssetURL(t *testing.T) {
+	assets := fstest.MapFS{"css/app.css": {Data: []byte("body{}")}}
+	version := strconv.FormatUint(uint64(crc32.ChecksumIEEE([]byte("body{}"))), 16)
+	tests := []struct {
+		name    string
+		config  Config
+		asset   string
+		want    string
+		wantErr bool
+	}{
+		{"default prefix", Config{AssetsFS: assets}, "css/app.css", "/css/app.css?v=" + version, false},
+		{"prefix", Config{AssetsFS: assets, AssetsPrefix: "/static"}, "css/app.css", "/static/css/app.css?v=" + version, false},
+		{"leading slash", Config{AssetsFS: assets, AssetsPrefix: "/static/"}, "/css/app.css", "/static/css/app.css?v=" + version, false},
+		{"missing", Config{AssetsFS: assets}, "css/missing.css", "", true},
+		{"no assets", Config{}, "css/app.css", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New(tt.config).AssetURL(tt.asset)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("AssetURL = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAssetURLCache(t *testing.T) {
+	tests := []struct {
+		name       string
+		release    bool
+		wantReload bool
+	}{
+		{"debug", false, true},
+		{"release", true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assets := fstest.MapFS{"app.js": {Data: []byte("v1")}}
+			e := New(Config{AssetsFS: assets})
+			if tt.release {
+				e.SetReleaseMode()
+			}
+			first, err := e.AssetURL("app.js")
+			if err != nil {
+				t.Fatal(err)
+			}
+			assets["app.js"] = &fstest.MapFile{Data: []byte("v2")}
+			second, err := e.AssetURL("app.js")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reloaded := first != second; reloaded != tt.wantReload {
+				t.Errorf("AssetURL %q then %q, want reload %t", first, second, tt.wantReload)
+			}
+		})
+	}
+}
+
+func TestViewData(t *testing.T) {
+	tests := []struct {
+		name string
+		data interface{}
+		want string
+	}{
+		{"map", Map{"title": "Home"}, "Seng|Home|ann|Seng"},
+		{"plain map", map[string]interface{}{"title": "Home", "site": "Mine"}, "Mine|Home|ann|Seng"},
+		{"nil", nil, "Seng||ann|Seng"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			views := fstest.MapFS{"page.html": {Data: []byte(`{{.site}}|{{.title}}|{{.user}}|{{local "site"}}`)}}
+			e := New(Config{Views: NewHTMLViewsFS(views, ".html")})
+			e.SetViewData("site", "Seng")
+			e.Use(func(c *Context) error {
+				c.SetViewData("user", "ann")
+				return c.Next()
+			})
+			e.GET("/", func(c *Context) error {
+				return c.Render("page", tt.data)
+			})
+			w := serve(t, e, http.MethodGet, "/", "")
+			if w.Body.String() != tt.want {
+				t.Errorf("body = %q, want %q", w.Body, tt.want)
+			}
+		})
+	}
+
+	// other data is passed unchanged, view data is read with local
+	views := fstest.MapFS{"page.html": {Data: []byte(`{{.Name}}|{{local "user"}}`)}}
+	e := New(Config{Views: NewHTMLViewsFS(views, ".html")})
+	e.GET("/", func(c *Context) error {
+		c.SetViewData("user", "ann")
+		return c.Render("page", struct{ Name string }{"bob"})
+	})
+	if w := serve(t, e, http.MethodGet, "/", ""); w.Body.String() != "bob|ann" {
+		t.Errorf("struct data: body = %q", w.Body)
+	}
+}
+
+func TestViewFuncs(t *testing.T) {
+	assets := fstest.MapFS{"app.css": {Data: []byte("x")}}
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{"url", `{{url "pet" "id" 7}}`, "/pets/7"},
+		{"csrf", `{{csrf}}`, "token"},
+		{"translate", `{{t "hello %s" "ann"}}`, "fr:hello %s:ann"},
+		{"asset", `{{asset "app.css"}}`, "/static/app.css?v=" + strconv.FormatUint(uint64(crc32.ChecksumIEEE([]byte("x"))), 16)},
+		{"local", `{{local "user"}}`, "ann"},
+		{"custom", `{{now}}`, "2024"},
+		{"replaced", `{{upper "a"}}`, "request A"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			views := fstest.MapFS{"page.html": {Data: []byte(tt.template)}}
+			e := New(Config{
+				Views:        NewHTMLViewsFS(views, ".html").Funcs(map[string]interface{}{"upper": strings.ToUpper}),
+				AssetsFS:     assets,
+				AssetsPrefix: "/static",
+				Translator: func(locale string, key string, args ...interface{}) string {
+					return locale + ":" + key + ":" + fmt.Sprint(args...)
+				},
+				ViewFuncs: map[string]ViewFunc{
+					"now": func(c *Context) interface{} {
+						return func() int { return time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Year() }
+					},
+					"upper": func(c *Context) interface{} {
+						return func(s string) string { return "request " + strings.ToUpper(s) }
+					},
+				},
+			})
+			e.GET("/pets/:id", func(c *Context) error { return nil }).Named("pet")
+			e.GET("/", func(c *Context) error {
+				c.Values[CSRFKey] = "token"
+				c.SetViewData("user", "ann")
+				return c.Render("page", nil)
+			})
+			w := serve(t, e, http.MethodGet, "/", "", HeaderAcceptLanguage, "fr")
+			if w.Code != http.StatusOK || w.Body.String() != tt.want {
+				t.Errorf("response = %d %q, want %q", w.Code, w.Body, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderToStringViewFuncs(t *testing.T) {
+	tests := []struct {
+		template string
+		want     string
+		wantErr  bool
+	}{
+		{`{{url "pet" "id" 1}}`, "/pets/1", false},
+		{`{{local "site"}}`, "Seng", false},
+		{`a{{flush}}b`, "ab", false},
+		{`{{range flashes}}x{{end}}`, "", false},
+		{`{{csrf}}`, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			views := fstest.MapFS{"mail.html": {Data: []byte(tt.template)}}
+			e := New(Config{Views: NewHTMLViewsFS(views, ".html")})
+			e.SetViewData("site", "Seng")
+			e.GET("/pets/:id", func(c *Context) error { return nil }).Named("pet")
+			got, err := e.RenderToString("mail", nil)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("RenderToString = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
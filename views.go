@@ -0,0 +1,356 @@
+package seng
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Views renders templates by name, see Config.Views.
+// Implementations other than HTMLViews, e.g. text/template for emails, only
+// need to implement Load and Render.
+type Views interface {
+	// Load parses the templates
+	Load() error
+	// Render executes the template name with data inside layouts
+	Render(w io.Writer, name string, data interface{}, layouts ...string) error
+}
+
+// ViewsReloader is implemented by Views which can reload changed templates,
+// Reload is called before rendering in debug mode
+type ViewsReloader interface {
+	// Reload loads the templates again if they changed
+	Reload() error
+}
+
+// FuncsRenderer is implemented by Views which can render with template
+// functions bound to the request, see Config.ViewFuncs
+type FuncsRenderer interface {
+	// RenderFuncs renders as Render with funcs replacing the template functions of the same name
+	RenderFuncs(w io.Writer, name string, data interface{}, funcs template.FuncMap, layouts ...string) error
+}
+
+// ErrViewNotFound is returned by Views.Render for unknown templates
+var ErrViewNotFound = errors.New("seng: view not found")
+
+// contentTemplate name of the view inside a layout
+const contentTemplate = "content"
+
+// HTMLViews is the html/template implementation of Views.
+//
+// Templates are named by their path relative to the directory without the
+// extension, e.g. "users/index", "layouts/main" and "partials/nav", and any
+// template can include another one as a partial: {{template "partials/nav" .}}
+//
+// A view rendered in layouts is the "content" template of the layouts, the
+// first layout is executed. Blocks defined by the view override the blocks of
+// the layouts, and blocks of a later layout override those of an earlier one.
+//
+//	layouts/main.html: <title>{{block "title" .}}App{{end}}</title>{{template "content" .}}
+//	users/index.html:  {{define "title"}}Users{{end}}<ul>{{range .}}<li>{{.Name}}</li>{{end}}</ul>
+//	c.Render("users/index", users, "layouts/main")
+type HTMLViews struct {
+	fsys  fs.FS
+	ext   string
+	funcs template.FuncMap
+
+	mutex sync.RWMutex
+	// every template parsed by name, never executed so that it can be cloned
+	base *template.Template
+	// sources by name
+	files map[string]string
+	// templates by view and layouts
+	cache map[string]*templateSet
+	// version of the loaded files, see Reload
+	version string
+}
+
+// NewHTMLViews creates views of the files with extension ext under dir
+// views := seng.NewHTMLViews("./views", ".html")
+func NewHTMLViews(dir string, ext string) *HTMLViews {
+	return NewHTMLViewsFS(os.DirFS(dir), ext)
+}
+
+// NewHTMLViewsFS creates views of the files with extension ext in fsys, e.g.
+// an embed.FS
+// views := seng.NewHTMLViewsFS(viewsFS, ".html")
+func NewHTMLViewsFS(fsys fs.FS, ext string) *HTMLViews {
+	return &HTMLViews{
+		fsys:  fsys,
+		ext:   ext,
+		funcs: make(template.FuncMap),
+	}
+}
+
+// Funcs adds template functions, it must be called before Load
+func (v *HTMLViews) Funcs(funcs template.FuncMap) *HTMLViews {
+	for name, fn := range funcs {
+		v.funcs[name] = fn
+	}
+	return v
+}
+
+// walk calls fn for the template files
+func (v *HTMLViews) walk(fn func(path string, d fs.DirEntry) error) error {
+	return fs.WalkDir(v.fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(path, v.ext) {
+			return nil
+		}
+		return fn(path, d)
+	})
+}
+
+// fileVersion returns a string which changes when a template file is added,
+// removed or modified
+func (v *HTMLViews) fileVersion() (string, error) {
+	var b strings.Builder
+	err := v.walk(func(path string, d fs.DirEntry) error {
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		writeFileVersion(&b, path, info)
+		return nil
+	})
+	return b.String(), err
+}
+
+// Load implements Views, it parses all templates
+func (v *HTMLViews) Load() error {
+	version, err := v.fileVersion()
+	if err != nil {
+		return fmt.Errorf("seng: views: %w", err)
+	}
+	files := make(map[string]string)
+	err = v.walk(func(path string, d fs.DirEntry) error {
+		data, err := fs.ReadFile(v.fsys, path)
+		if err != nil {
+			return err
+		}
+		files[strings.TrimSuffix(path, v.ext)] = string(data)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("seng: views: %w", err)
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	// parse in a stable order, later definitions of a block win
+	sort.Strings(names)
+	base := template.New("").Funcs(v.funcs)
+	for _, name := range names {
+		if _, err := base.New(name).Parse(files[name]); err != nil {
+			return fmt.Errorf("seng: views: %w", err)
+		}
+	}
+	v.mutex.Lock()
+	v.base = base
+	v.files = files
+	v.cache = make(map[string]*templateSet)
+	v.version = version
+	v.mutex.Unlock()
+	return nil
+}
+
+// Reload implements ViewsReloader, it loads the templates again when a file
+// was added, removed or modified
+func (v *HTMLViews) Reload() error {
+	version, err := v.fileVersion()
+	if err != nil {
+		return fmt.Errorf("seng: views: %w", err)
+	}
+	v.mutex.RLock()
+	changed := version != v.version
+	v.mutex.RUnlock()
+	if !changed {
+		return nil
+	}
+	return v.Load()
+}
+
+// Render implements Views
+func (v *HTMLViews) Render(w io.Writer, name string, data interface{}, layouts ...string) error {
+	return v.RenderFuncs(w, name, data, nil, layouts...)
+}
+
+// RenderFuncs implements FuncsRenderer, funcs must have been declared with
+// Funcs before Load. Templates are parsed once per view and layouts.
+func (v *HTMLViews) RenderFuncs(w io.Writer, name string, data interface{}, funcs template.FuncMap, layouts ...string) error {
+	set, err := v.lookup(name, layouts)
+	if err != nil {
+		return err
+	}
+	entry := name
+	if len(layouts) > 0 {
+		entry = layouts[0]
+	}
+	return set.execute(w, entry, data, funcs)
+}
+
+// lookup returns the template set of name in layouts, parsing it once
+func (v *HTMLViews) lookup(name string, layouts []string) (*templateSet, error) {
+	key := name + "\x00" + strings.Join(layouts, "\x00")
+	v.mutex.RLock()
+	set, ok := v.cache[key]
+	base, files := v.base, v.files
+	v.mutex.RUnlock()
+	if ok {
+		return set, nil
+	}
+	if base == nil {
+		return nil, errors.New("seng: views are not loaded")
+	}
+	for _, n := range append([]string{name}, layouts...) {
+		if _, ok := files[n]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrViewNotFound, n)
+		}
+	}
+
+	t, err := base.Clone()
+	if err != nil {
+		return nil, err
+	}
+	// parse the layouts then the view again so that their blocks win
+	for _, layout := range layouts {
+		if _, err := t.New(layout).Parse(files[layout]); err != nil {
+			return nil, err
+		}
+	}
+	viewName := name
+	if len(layouts) > 0 {
+		viewName = contentTemplate
+	}
+	if _, err := t.New(viewName).Parse(files[name]); err != nil {
+		return nil, err
+	}
+
+	set = newTemplateSet(t, v.funcs)
+	v.mutex.Lock()
+	// not cached if the views were loaded again meanwhile
+	if v.base == base {
+		v.cache[key] = set
+	}
+	v.mutex.Unlock()
+	return set, nil
+}
+
+// bufferPool buffers of rendered templates
+var bufferPool = sync.Pool{New: func() interface{} {
+	return new(bytes.Buffer)
+}}
+
+// Render renders the view name inside layouts with Config.Views, or the
+// HTML templates of LoadHTMLGlob without layouts. The page is buffered, so a
+// template error results in a clean error response.
+// return c.Render("users/index", users, "layouts/main")
+func (c *Context) Render(name string, data interface{}, layouts ...string) error {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer bufferPool.Put(buf)
+	if err := c.renderHTML(buf, name, data, layouts...); err != nil {
+		return err
+	}
+	return c.writeContent(MINETextHTML, buf.Bytes())
+}
+
+// RenderStream renders as Render without buffering, for long pages. The
+// template function flush sends the page rendered so far, e.g. the head so
+// that the browser loads assets early. An error once the page is sent ends
+// the page and is logged.
+//
+//	<head>...</head>{{flush}}<body>{{range .Rows}}...{{end}}</body>
+func (c *Context) RenderStream(name string, data interface{}, layouts ...string) error {
+	c.SetHeader(HeaderContentType, MINETextHTML)
+	c.streaming = true
+	defer func() { c.streaming = false }()
+	err := c.renderHTML(c.Writer, name, data, layouts...)
+	if err != nil && c.response.Written() {
+		c.engine.Logger.Printf("seng: render %s: %v", name, err)
+		return nil
+	}
+	return err
+}
+
+// renderHTML renders with the view data and view functions of c
+func (c *Context) renderHTML(w io.Writer, name string, data interface{}, layouts ...string) error {
+	return c.engine.renderHTML(w, name, c.mergeViewData(data), c.viewFuncMap(), layouts...)
+}
+
+// RenderToString renders as Context.Render outside of requests, e.g. emails.
+// Template functions bound to requests other than url, asset and local fail.
+func (e *Engine) RenderToString(name string, data interface{}, layouts ...string) (string, error) {
+	buf := new(bytes.Buffer)
+	err := e.renderHTML(buf, name, mergeViewData(e.viewData, data), e.engineViewFuncs(), layouts...)
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// renderHTML renders with Config.Views, or the HTML templates without layouts
+func (e *Engine) renderHTML(w io.Writer, name string, data interface{}, funcs template.FuncMap, layouts ...string) error {
+	if e.config.Views != nil {
+		return e.renderView(w, name, data, funcs, layouts...)
+	}
+	if len(layouts) > 0 {
+		return errors.New("seng: layouts need Config.Views")
+	}
+	return e.executeTemplate(w, name, data, funcs)
+}
+
+// renderView renders with Config.Views
+func (e *Engine) renderView(w io.Writer, name string, data interface{}, funcs template.FuncMap, layouts ...string) error {
+	views, err := e.views()
+	if err != nil {
+		return err
+	}
+	if renderer, ok := views.(FuncsRenderer); ok {
+		return renderer.RenderFuncs(w, name, data, funcs, layouts...)
+	}
+	return views.Render(w, name, data, layouts...)
+}
+
+// LoadViews loads Config.Views with the view functions of the engine. New
+// loads them and logs the error, call LoadViews to handle it:
+//
+//	e := seng.New(seng.Config{Views: views})
+//	if err := e.LoadViews(); err != nil {
+//		log.Fatal(err)
+//	}
+func (e *Engine) LoadViews() error {
+	if e.config.Views == nil {
+		return nil
+	}
+	e.viewsErr = e.config.Views.Load()
+	return e.viewsErr
+}
+
+// views returns Config.Views, reloaded in debug mode
+func (e *Engine) views() (Views, error) {
+	views := e.config.Views
+	if views == nil {
+		return nil, errors.New("seng: Config.Views is not set")
+	}
+	if reloader, ok := views.(ViewsReloader); ok && e.config.Debug {
+		if err := reloader.Reload(); err != nil {
+			return nil, err
+		}
+		return views, nil
+	}
+	if e.viewsErr != nil {
+		return nil, e.viewsErr
+	}
+	return views, nil
+}
@@ -0,0 +1,244 @@
+package seng
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+)
+
+// viewsFS views with layouts, partials and blocks
+var viewsFS = fstest.MapFS{
+	"layouts/main.html":  {Data: []byte(`<title>{{block "title" .}}App{{end}}</title>{{template "partials/nav" .}}<main>{{template "content" .}}</main>`)},
+	"layouts/admin.html": {Data: []byte(`{{define "title"}}Admin{{end}}`)},
+	"partials/nav.html":  {Data: []byte(`<nav>{{upper "home"}}</nav>`)},
+	"users/index.html":   {Data: []byte(`{{define "title"}}Users{{end}}<ul>{{range .}}<li>{{.}}</li>{{end}}</ul>`)},
+	"users/show.html":    {Data: []byte(`<p>{{.}}</p>`)},
+	"broken/exec.html":   {Data: []byte(`{{template "partials/missing" .}}`)},
+	"notes.txt":          {Data: []byte(`{{not a template`)},
+}
+
+func newTestViews(t *testing.T) *HTMLViews {
+	t.Helper()
+	views := NewHTMLViewsFS(viewsFS, ".html").Funcs(template.FuncMap{"upper": strings.ToUpper})
+	if err := views.Load(); err != nil {
+		t.Fatal(err)
+	}
+	return views
+}
+
+func TestHTMLViewsRender(t *testing.T) {
+	tests := []struct {
+		name    string
+		view    string
+		data    interface{}
+		layouts []string
+		want    string
+		wantErr error
+	}{
+		{"view", "users/show", "<b>", nil, `<p>&lt;b&gt;</p>`, nil},
+		{"partial", "partials/nav", nil, nil, `<nav>HOME</nav>`, nil},
+		{"layout", "users/show", "a", []string{"layouts/main"},
+			`<title>App</title><nav>HOME</nav><main><p>a</p></main>`, nil},
+		{"view block", "users/index", []string{"a", "b"}, []string{"layouts/main"},
+			`<title>Users</title><nav>HOME</nav><main><ul><li>a</li><li>b</li></ul></main>`, nil},
+		{"later layout block", "users/show", "a", []string{"layouts/main", "layouts/admin"},
+			`<title>Admin</title><nav>HOME</nav><main><p>a</p></main>`, nil},
+		{"view block wins over layouts", "users/index", nil, []string{"layouts/main", "layouts/admin"},
+			`<title>Users</title><nav>HOME</nav><main><ul></ul></main>`, nil},
+		{"unknown view", "users/missing", nil, nil, "", ErrViewNotFound},
+		{"unknown layout", "users/show", nil, []string{"layouts/missing"}, "", ErrViewNotFound},
+		{"other extension", "notes", nil, nil, "", ErrViewNotFound},
+		{"exec error", "broken/exec", nil, nil, "", nil},
+	}
+	views := newTestViews(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			err := views.Render(buf, tt.view, tt.data, tt.layouts...)
+			if tt.want == "" {
+				if err == nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+					t.Errorf("Render = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("Render = %q, want %q", buf, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTMLViewsErrors(t *testing.T) {
+	if err := NewHTMLViewsFS(viewsFS, ".html").Render(io.Discard, "users/show", nil); err == nil {
+		t.Error("Render before Load = nil error")
+	}
+	broken := fstest.MapFS{"a.html": {Data: []byte(`{{if}}`)}}
+	if err := NewHTMLViewsFS(broken, ".html").Load(); err == nil || !strings.HasPrefix(err.Error(), "seng: views:") {
+		t.Errorf("Load = %v, want a parse error", err)
+	}
+	// functions must be declared before Load
+	if err := NewHTMLViewsFS(viewsFS, ".html").Load(); err == nil {
+		t.Error("Load without upper = nil error")
+	}
+}
+
+func TestLoadViews(t *testing.T) {
+	for _, release := range []bool{false, true} {
+		fsys := fstest.MapFS{"index.html": {Data: []byte(`{{if}}`)}}
+		e := New(Config{Views: NewHTMLViewsFS(fsys, ".html")})
+		if release {
+			e.SetReleaseMode()
+		}
+		var renderErr error
+		e.GET("/", func(c *Context) error {
+			renderErr = c.Render("index", nil)
+			return renderErr
+		})
+		if err := e.LoadViews(); err == nil || !strings.HasPrefix(err.Error(), "seng: views:") {
+			t.Fatalf("release %t: LoadViews = %v, want a parse error", release, err)
+		}
+		// rendering returns the load error, not that the views are not loaded
+		serve(t, e, http.MethodGet, "/", "")
+		if renderErr == nil || !strings.HasPrefix(renderErr.Error(), "seng: views:") {
+			t.Errorf("release %t: Render = %v, want the load error", release, renderErr)
+		}
+
+		fsys["index.html"] = &fstest.MapFile{Data: []byte("fixed")}
+		if err := e.LoadViews(); err != nil {
+			t.Fatal(err)
+		}
+		if got := serve(t, e, http.MethodGet, "/", "").Body.String(); got != "fixed" {
+			t.Errorf("release %t: body = %q after the views loaded", release, got)
+		}
+	}
+	if err := New().LoadViews(); err != nil {
+		t.Errorf("LoadViews without views = %v", err)
+	}
+}
+
+func TestHTMLViewsRenderFuncs(t *testing.T) {
+	views := newTestViews(t)
+	render := func(funcs template.FuncMap) string {
+		buf := new(bytes.Buffer)
+		if err := views.RenderFuncs(buf, "partials/nav", nil, funcs); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+	if got := render(template.FuncMap{"upper": func(s string) string { return "[" + s + "]" }}); got != "<nav>[home]</nav>" {
+		t.Errorf("RenderFuncs = %q", got)
+	}
+	// the replacement does not leak into later renders
+	if got := render(nil); got != "<nav>HOME</nav>" {
+		t.Errorf("Render after RenderFuncs = %q", got)
+	}
+}
+
+func TestHTMLViewsConcurrent(t *testing.T) {
+	views := newTestViews(t)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				buf := new(bytes.Buffer)
+				if err := views.Render(buf, "users/show", "a", "layouts/main"); err != nil {
+					t.Error(err)
+					return
+				}
+				if !strings.Contains(buf.String(), "<p>a</p>") {
+					t.Errorf("Render = %q", buf)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// textViews a Views implementation without FuncsRenderer
+type textViews struct {
+	loaded bool
+}
+
+func (v *textViews) Load() error {
+	v.loaded = true
+	return nil
+}
+
+func (v *textViews) Render(w io.Writer, name string, data interface{}, layouts ...string) error {
+	if name != "hello" {
+		return ErrViewNotFound
+	}
+	_, err := io.WriteString(w, "hello "+strings.Join(layouts, ","))
+	return err
+}
+
+func TestContextRender(t *testing.T) {
+	tests := []struct {
+		name     string
+		views    Views
+		view     string
+		layouts  []string
+		wantCode int
+		wantBody string
+	}{
+		{"html views", nil, "users/show", []string{"layouts/main"}, http.StatusOK, `<title>App</title><nav>HOME</nav><main><p>a</p></main>`},
+		{"custom views", &textViews{}, "hello", []string{"l"}, http.StatusOK, "hello l"},
+		{"not found", nil, "users/missing", nil, http.StatusInternalServerError, "Internal Server Error"},
+		// the page is buffered, nothing of the failed page is sent
+		{"exec error", nil, "broken/exec", nil, http.StatusInternalServerError, "Internal Server Error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			views := tt.views
+			if views == nil {
+				views = NewHTMLViewsFS(viewsFS, ".html").Funcs(template.FuncMap{"upper": strings.ToUpper})
+			}
+			e := New(Config{Views: views})
+			e.GET("/", func(c *Context) error {
+				return c.Render(tt.view, "a", tt.layouts...)
+			})
+			w := serve(t, e, http.MethodGet, "/", "")
+			if w.Code != tt.wantCode || w.Body.String() != tt.wantBody {
+				t.Errorf("response = %d %q, want %d %q", w.Code, w.Body, tt.wantCode, tt.wantBody)
+			}
+			if tt.wantCode == http.StatusOK && w.Header().Get(HeaderContentType) != MINETextHTML {
+				t.Errorf("Content-Type = %q", w.Header().Get(HeaderContentType))
+			}
+		})
+	}
+
+	e := New()
+	e.GET("/", func(c *Context) error {
+		return c.Render("users/show", "a", "layouts/main")
+	})
+	if w := serve(t, e, http.MethodGet, "/", ""); w.Code != http.StatusInternalServerError {
+		t.Errorf("layouts without views: status = %d", w.Code)
+	}
+}
+
+func TestRenderToString(t *testing.T) {
+	views := NewHTMLViewsFS(viewsFS, ".html").Funcs(template.FuncMap{"upper": strings.ToUpper})
+	e := New(Config{Views: views})
+	got, err := e.RenderToString("users/index", []string{"a"}, "layouts/main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `<title>Users</title><nav>HOME</nav><main><ul><li>a</li></ul></main>`; got != want {
+		t.Errorf("RenderToString = %q, want %q", got, want)
+	}
+	if _, err := e.RenderToString("users/missing", nil); !errors.Is(err, ErrViewNotFound) {
+		t.Errorf("RenderToString = %v, want ErrViewNotFound", err)
+	}
+}
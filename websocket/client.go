@@ -0,0 +1,219 @@
+package websocket
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Dialer opens client connections
+type Dialer struct {
+	// Subprotocols requested in order of preference
+	Subprotocols []string
+	// EnableCompression offers permessage-deflate
+	EnableCompression bool
+	// CompressionLevel see Config.CompressionLevel, Dial fails on invalid levels
+	// Default: flate.BestSpeed
+	CompressionLevel int
+	// Default: 4 * 1024 * 1024
+	ReadLimit int64
+	// Default: 4096
+	WriteBufferSize int
+	// HandshakeTimeout Default: unlimited
+	HandshakeTimeout time.Duration
+	// TLSClientConfig of wss:// connections
+	TLSClientConfig *tls.Config
+	// NetDial replaces net.Dial
+	NetDial func(network, addr string) (net.Conn, error)
+}
+
+// DefaultDialer used by Dial
+var DefaultDialer = &Dialer{}
+
+// Dial connects to a ws:// or wss:// url with DefaultDialer
+func Dial(rawURL string, header http.Header) (*Conn, *http.Response, error) {
+	return DefaultDialer.Dial(rawURL, header)
+}
+
+// Dial connects to a ws:// or wss:// url, the response is returned with
+// ErrBadHandshake when the server refuses the upgrade
+func (d *Dialer) Dial(rawURL string, header http.Header) (*Conn, *http.Response, error) {
+	if _, err := d.config(); err != nil {
+		return nil, nil, err
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	secure := false
+	switch u.Scheme {
+	case "ws", "http":
+		u.Scheme = "http"
+	case "wss", "https":
+		u.Scheme = "https"
+		secure = true
+	default:
+		return nil, nil, errors.New("websocket: unsupported url scheme " + u.Scheme)
+	}
+	addr := u.Host
+	if u.Port() == "" {
+		if secure {
+			addr = net.JoinHostPort(u.Hostname(), "443")
+		} else {
+			addr = net.JoinHostPort(u.Hostname(), "80")
+		}
+	}
+
+	netDial := d.NetDial
+	if netDial == nil {
+		dialer := &net.Dialer{Timeout: d.HandshakeTimeout}
+		netDial = dialer.Dial
+	}
+	netConn, err := netDial("tcp", addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	if secure {
+		cfg := d.TLSClientConfig.Clone()
+		if cfg == nil {
+			cfg = &tls.Config{}
+		}
+		if cfg.ServerName == "" {
+			cfg.ServerName = u.Hostname()
+		}
+		netConn = tls.Client(netConn, cfg)
+	}
+	conn, resp, err := d.handshake(netConn, u, header)
+	if err != nil {
+		_ = netConn.Close()
+		return nil, resp, err
+	}
+	return conn, resp, nil
+}
+
+// config returns the connection config of the dialer
+func (d *Dialer) config() (*Config, error) {
+	cfg := &Config{
+		CompressionLevel: d.CompressionLevel,
+		ReadLimit:        d.ReadLimit,
+		WriteBufferSize:  d.WriteBufferSize,
+	}
+	if err := cfg.setDefaults(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// handshake sends the upgrade request and checks the response
+func (d *Dialer) handshake(netConn net.Conn, u *url.URL, header http.Header) (*Conn, *http.Response, error) {
+	if d.HandshakeTimeout > 0 {
+		_ = netConn.SetDeadline(time.Now().Add(d.HandshakeTimeout))
+		defer netConn.SetDeadline(time.Time{})
+	}
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce)
+
+	req := &http.Request{
+		Method:     http.MethodGet,
+		URL:        u,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     make(http.Header),
+		Host:       u.Host,
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	if len(d.Subprotocols) > 0 {
+		req.Header.Set("Sec-WebSocket-Protocol", strings.Join(d.Subprotocols, ", "))
+	}
+	if d.EnableCompression {
+		req.Header.Set("Sec-WebSocket-Extensions", deflateNoContextTakeoverOptions)
+	}
+	if err := req.Write(netConn); err != nil {
+		return nil, nil, err
+	}
+
+	reader := bufio.NewReader(netConn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols ||
+		!headerContainsToken(resp.Header, "Upgrade", "websocket") ||
+		!headerContainsToken(resp.Header, "Connection", "upgrade") ||
+		resp.Header.Get("Sec-WebSocket-Accept") != acceptKey(key) {
+		return nil, resp, ErrBadHandshake
+	}
+
+	cfg, err := d.config()
+	if err != nil {
+		return nil, nil, err
+	}
+	conn := newConn(netConn, reader, false, cfg)
+	conn.subprotocol = resp.Header.Get("Sec-WebSocket-Protocol")
+	for _, ext := range parseExtensions(resp.Header) {
+		if ext.name != extensionDeflate || !d.EnableCompression {
+			return nil, resp, ErrBadHandshake
+		}
+		// we always compress with a full window
+		if bits, ok := ext.params[paramClientMaxWindowBits]; ok && bits != "" && bits != "15" {
+			return nil, resp, ErrBadHandshake
+		}
+		conn.compress = true
+	}
+	return conn, resp, nil
+}
+
+// DialHandler serves handler in-process on a loopback listener accepting a
+// single connection and connects to it, for tests
+//
+//	conn, _, err := websocket.DialHandler(engine, "ws://localhost/ws/lobby", nil)
+func DialHandler(handler http.Handler, rawURL string, header http.Header, dialer ...*Dialer) (*Conn, *http.Response, error) {
+	d := Dialer{}
+	if len(dialer) > 0 && dialer[0] != nil {
+		d = *dialer[0]
+	}
+	netDial := d.NetDial
+	if netDial == nil {
+		netDial = net.Dial
+	}
+	d.NetDial = func(network, addr string) (net.Conn, error) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			return nil, err
+		}
+		go func() {
+			_ = (&http.Server{Handler: handler}).Serve(&onceListener{Listener: listener})
+		}()
+		return netDial(network, listener.Addr().String())
+	}
+	return d.Dial(rawURL, header)
+}
+
+// onceListener closes itself after accepting a connection
+type onceListener struct {
+	net.Listener
+}
+
+// Accept implements net.Listener
+func (l *onceListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	_ = l.Listener.Close()
+	return conn, err
+}
@@ -0,0 +1,67 @@
+package websocket
+
+import (
+	"bytes"
+	"compress/flate"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+)
+
+// deflateTail is removed from compressed messages by RFC 7692
+var deflateTail = []byte{0x00, 0x00, 0xff, 0xff}
+
+// deflateFinal an empty final block ending the stream of a message
+var deflateFinal = []byte{0x01, 0x00, 0x00, 0xff, 0xff}
+
+var (
+	flateWriterPools [flate.BestCompression - flate.HuffmanOnly + 1]sync.Pool
+	flateReaderPool  sync.Pool
+)
+
+// compress deflates a message without context takeover
+func compress(data []byte, level int) ([]byte, error) {
+	if level < flate.HuffmanOnly || level > flate.BestCompression {
+		return nil, fmt.Errorf("websocket: invalid compression level %d", level)
+	}
+	buf := new(bytes.Buffer)
+	pool := &flateWriterPools[level-flate.HuffmanOnly]
+	w, _ := pool.Get().(*flate.Writer)
+	if w == nil {
+		var err error
+		if w, err = flate.NewWriter(buf, level); err != nil {
+			return nil, err
+		}
+	} else {
+		w.Reset(buf)
+	}
+	defer pool.Put(w)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), deflateTail), nil
+}
+
+// decompress inflates a message, larger than limit is a CloseMessageTooBig error
+func decompress(data []byte, limit int64) ([]byte, error) {
+	src := io.MultiReader(bytes.NewReader(data), bytes.NewReader(deflateTail), bytes.NewReader(deflateFinal))
+	r, _ := flateReaderPool.Get().(io.ReadCloser)
+	if r == nil {
+		r = flate.NewReader(src)
+	} else if err := r.(flate.Resetter).Reset(src, nil); err != nil {
+		return nil, err
+	}
+	defer flateReaderPool.Put(r)
+	out, err := ioutil.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, &CloseError{Code: CloseInvalidFramePayloadData, Text: "invalid compressed data"}
+	}
+	if int64(len(out)) > limit {
+		return nil, &CloseError{Code: CloseMessageTooBig, Text: "message too big"}
+	}
+	return out, nil
+}
@@ -0,0 +1,497 @@
+package websocket
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// frame header bits
+const (
+	finBit  = 1 << 7
+	rsv1Bit = 1 << 6
+	rsv2Bit = 1 << 5
+	rsv3Bit = 1 << 4
+	maskBit = 1 << 7
+
+	maxControlPayload = 125
+)
+
+// Conn is a WebSocket connection.
+// One goroutine may read and any number of goroutines may write concurrently.
+type Conn struct {
+	// Params route params of the upgrading request
+	Params map[string]string
+	// Values context values of the upgrading request
+	Values map[string]interface{}
+	// Request the upgrading request, nil for client connections
+	Request *http.Request
+
+	conn             net.Conn
+	reader           *bufio.Reader
+	server           bool
+	subprotocol      string
+	compress         bool
+	compressionLevel int
+	readLimit        int64
+	writeBufferSize  int
+
+	readErr     error
+	pingHandler func(data []byte) error
+	pongHandler func(data []byte) error
+
+	writeMutex sync.Mutex
+	closeSent  bool
+}
+
+// frameHeader of a received frame
+type frameHeader struct {
+	fin    bool
+	rsv1   bool
+	opcode int
+	length int64
+	masked bool
+	mask   [4]byte
+}
+
+func newConn(conn net.Conn, reader *bufio.Reader, server bool, cfg *Config) *Conn {
+	if reader == nil {
+		reader = bufio.NewReader(conn)
+	}
+	c := &Conn{
+		conn:             conn,
+		reader:           reader,
+		server:           server,
+		compressionLevel: cfg.CompressionLevel,
+		readLimit:        cfg.ReadLimit,
+		writeBufferSize:  cfg.WriteBufferSize,
+	}
+	c.pingHandler = c.defaultPingHandler
+	c.pongHandler = func([]byte) error { return nil }
+	return c
+}
+
+// Subprotocol returns the negotiated subprotocol
+func (c *Conn) Subprotocol() string {
+	return c.subprotocol
+}
+
+// Compressed reports whether permessage-deflate was negotiated
+func (c *Conn) Compressed() bool {
+	return c.compress
+}
+
+// LocalAddr returns the local network address
+func (c *Conn) LocalAddr() net.Addr {
+	return c.conn.LocalAddr()
+}
+
+// RemoteAddr returns the remote network address
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// SetReadDeadline sets the deadline of ReadMessage
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets the deadline of writes
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
+
+// SetReadLimit sets the max size of a message, 0 or less is DefaultReadLimit
+func (c *Conn) SetReadLimit(limit int64) {
+	if limit <= 0 {
+		limit = DefaultReadLimit
+	}
+	c.readLimit = limit
+}
+
+// SetPingHandler sets the handler of ping frames, the default one sends a pong
+func (c *Conn) SetPingHandler(handler func(data []byte) error) {
+	if handler == nil {
+		handler = c.defaultPingHandler
+	}
+	c.pingHandler = handler
+}
+
+// SetPongHandler sets the handler of pong frames
+func (c *Conn) SetPongHandler(handler func(data []byte) error) {
+	if handler == nil {
+		handler = func([]byte) error { return nil }
+	}
+	c.pongHandler = handler
+}
+
+func (c *Conn) defaultPingHandler(data []byte) error {
+	err := c.WriteControl(PongMessage, data)
+	if err == ErrCloseSent {
+		return nil
+	}
+	return err
+}
+
+// ReadMessage reads the next text or binary message, handling control frames.
+// When the peer closes the connection or violates the protocol a close frame is
+// sent and a *CloseError is returned, later calls return the same error.
+func (c *Conn) ReadMessage() (messageType int, data []byte, err error) {
+	if c.readErr != nil {
+		return 0, nil, c.readErr
+	}
+	messageType, data, err = c.readMessage()
+	if err != nil {
+		c.readErr = err
+		var closeErr *CloseError
+		if errors.As(err, &closeErr) {
+			_ = c.WriteClose(closeErr.Code, closeErr.Text)
+		}
+	}
+	return
+}
+
+// ReadJSON reads the next message as JSON into v
+func (c *Conn) ReadJSON(v interface{}) error {
+	_, data, err := c.ReadMessage()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func (c *Conn) readMessage() (int, []byte, error) {
+	var (
+		messageType int
+		compressed  bool
+		buf         bytes.Buffer
+	)
+	for {
+		h, err := c.readFrameHeader()
+		if err != nil {
+			return 0, nil, err
+		}
+		if h.opcode >= CloseMessage {
+			var payload bytes.Buffer
+			if err := c.readPayload(h, &payload); err != nil {
+				return 0, nil, err
+			}
+			if err := c.handleControl(h.opcode, payload.Bytes()); err != nil {
+				return 0, nil, err
+			}
+			continue
+		}
+		if h.opcode == continuationFrame {
+			if messageType == 0 {
+				return 0, nil, protocolError("unexpected continuation frame")
+			}
+			if h.rsv1 {
+				return 0, nil, protocolError("RSV1 set on a continuation frame")
+			}
+		} else {
+			if messageType != 0 {
+				return 0, nil, protocolError("expected a continuation frame")
+			}
+			messageType, compressed = h.opcode, h.rsv1
+		}
+		if err := c.readPayload(h, &buf); err != nil {
+			return 0, nil, err
+		}
+		if h.fin {
+			break
+		}
+	}
+	data := buf.Bytes()
+	if compressed {
+		var err error
+		if data, err = decompress(data, c.readLimit); err != nil {
+			return 0, nil, err
+		}
+	}
+	if messageType == TextMessage && !utf8.Valid(data) {
+		return 0, nil, &CloseError{Code: CloseInvalidFramePayloadData, Text: "invalid UTF-8"}
+	}
+	return messageType, data, nil
+}
+
+func (c *Conn) readFrameHeader() (frameHeader, error) {
+	var h frameHeader
+	var b [8]byte
+	if _, err := io.ReadFull(c.reader, b[:2]); err != nil {
+		return h, err
+	}
+	if b[0]&(rsv2Bit|rsv3Bit) != 0 {
+		return h, protocolError("RSV2 or RSV3 set")
+	}
+	h.fin = b[0]&finBit != 0
+	h.rsv1 = b[0]&rsv1Bit != 0
+	h.opcode = int(b[0] & 0x0f)
+	h.masked = b[1]&maskBit != 0
+	h.length = int64(b[1] & 0x7f)
+	switch h.length {
+	case 126:
+		if _, err := io.ReadFull(c.reader, b[:2]); err != nil {
+			return h, err
+		}
+		h.length = int64(binary.BigEndian.Uint16(b[:2]))
+	case 127:
+		if _, err := io.ReadFull(c.reader, b[:8]); err != nil {
+			return h, err
+		}
+		length := binary.BigEndian.Uint64(b[:8])
+		if length>>63 != 0 {
+			return h, protocolError("invalid payload length")
+		}
+		h.length = int64(length)
+	}
+	if h.masked {
+		if _, err := io.ReadFull(c.reader, h.mask[:]); err != nil {
+			return h, err
+		}
+	}
+
+	switch h.opcode {
+	case continuationFrame, TextMessage, BinaryMessage:
+		if h.rsv1 && !c.compress {
+			return h, protocolError("RSV1 set without compression")
+		}
+	case CloseMessage, PingMessage, PongMessage:
+		if !h.fin {
+			return h, protocolError("fragmented control frame")
+		}
+		if h.length > maxControlPayload {
+			return h, protocolError("control frame too long")
+		}
+		if h.rsv1 {
+			return h, protocolError("RSV1 set on a control frame")
+		}
+	default:
+		return h, protocolError("unknown opcode")
+	}
+	// clients mask their frames, servers do not
+	if h.masked != c.server {
+		if c.server {
+			return h, protocolError("unmasked client frame")
+		}
+		return h, protocolError("masked server frame")
+	}
+	return h, nil
+}
+
+// readPayload appends the payload of a frame to the message in buf.
+// The buffer grows with the data received, not with the length the peer sent.
+func (c *Conn) readPayload(h frameHeader, buf *bytes.Buffer) error {
+	if int64(buf.Len())+h.length > c.readLimit {
+		return &CloseError{Code: CloseMessageTooBig, Text: "message too big"}
+	}
+	start := buf.Len()
+	if _, err := io.CopyN(buf, c.reader, h.length); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	if h.masked {
+		maskBytes(h.mask, buf.Bytes()[start:])
+	}
+	return nil
+}
+
+// handleControl handles ping, pong and close frames
+func (c *Conn) handleControl(opcode int, payload []byte) error {
+	switch opcode {
+	case PingMessage:
+		return c.pingHandler(payload)
+	case PongMessage:
+		return c.pongHandler(payload)
+	}
+	switch len(payload) {
+	case 0:
+		return &CloseError{Code: CloseNoStatusReceived}
+	case 1:
+		return protocolError("invalid close payload")
+	}
+	code := int(binary.BigEndian.Uint16(payload))
+	if !validCloseCode(code) {
+		return protocolError("invalid close code")
+	}
+	text := payload[2:]
+	if !utf8.Valid(text) {
+		return &CloseError{Code: CloseInvalidFramePayloadData, Text: "invalid UTF-8"}
+	}
+	return &CloseError{Code: code, Text: string(text)}
+}
+
+// validCloseCode reports whether code may be sent in a close frame
+func validCloseCode(code int) bool {
+	switch {
+	case code >= 3000 && code <= 4999:
+		return true
+	case code < CloseNormalClosure, code > CloseTryAgainLater:
+		return false
+	}
+	switch code {
+	case 1004, CloseNoStatusReceived, CloseAbnormalClosure:
+		return false
+	}
+	return true
+}
+
+func protocolError(text string) error {
+	return &CloseError{Code: CloseProtocolError, Text: text}
+}
+
+// WriteMessage writes a message, messages larger than WriteBufferSize are fragmented
+func (c *Conn) WriteMessage(messageType int, data []byte) error {
+	switch messageType {
+	case TextMessage, BinaryMessage:
+	case CloseMessage, PingMessage, PongMessage:
+		return c.WriteControl(messageType, data)
+	default:
+		return errors.New("websocket: unknown message type")
+	}
+	compressed := c.compress
+	if compressed {
+		var err error
+		if data, err = compress(data, c.compressionLevel); err != nil {
+			return err
+		}
+	}
+
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+	if c.closeSent {
+		return ErrCloseSent
+	}
+	opcode := messageType
+	for {
+		payload, fin := data, true
+		if c.writeBufferSize > 0 && len(payload) > c.writeBufferSize {
+			payload, fin = data[:c.writeBufferSize], false
+		}
+		if err := c.writeFrame(fin, compressed, opcode, payload); err != nil {
+			return err
+		}
+		if fin {
+			return nil
+		}
+		data = data[len(payload):]
+		// RSV1 is only set on the first frame
+		opcode, compressed = continuationFrame, false
+	}
+}
+
+// WriteText writes a text message
+func (c *Conn) WriteText(text string) error {
+	return c.WriteMessage(TextMessage, []byte(text))
+}
+
+// WriteJSON writes v as a JSON text message
+func (c *Conn) WriteJSON(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.WriteMessage(TextMessage, data)
+}
+
+// WriteControl writes a ping, pong or close frame
+func (c *Conn) WriteControl(messageType int, data []byte) error {
+	switch messageType {
+	case CloseMessage, PingMessage, PongMessage:
+	default:
+		return errors.New("websocket: not a control message")
+	}
+	if len(data) > maxControlPayload {
+		return errors.New("websocket: control payload too long")
+	}
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+	if c.closeSent {
+		return ErrCloseSent
+	}
+	if messageType == CloseMessage {
+		c.closeSent = true
+	}
+	return c.writeFrame(true, false, messageType, data)
+}
+
+// WriteClose sends a close frame with code and text, once
+func (c *Conn) WriteClose(code int, text string) error {
+	var payload []byte
+	if code != CloseNoStatusReceived {
+		payload = make([]byte, 2, 2+len(text))
+		binary.BigEndian.PutUint16(payload, uint16(code))
+		payload = append(payload, text...)
+		if len(payload) > maxControlPayload {
+			payload = payload[:maxControlPayload]
+		}
+	}
+	err := c.WriteControl(CloseMessage, payload)
+	if err == ErrCloseSent {
+		return nil
+	}
+	return err
+}
+
+// Close sends a normal closure frame if none was sent and closes the connection
+func (c *Conn) Close() error {
+	_ = c.WriteClose(CloseNormalClosure, "")
+	return c.conn.Close()
+}
+
+// writeFrame writes a frame, the caller holds writeMutex
+func (c *Conn) writeFrame(fin, rsv1 bool, opcode int, payload []byte) error {
+	header := make([]byte, 2, 14)
+	header[0] = byte(opcode)
+	if fin {
+		header[0] |= finBit
+	}
+	if rsv1 {
+		header[0] |= rsv1Bit
+	}
+	length := len(payload)
+	switch {
+	case length <= 125:
+		header[1] = byte(length)
+	case length <= 0xffff:
+		header[1] = 126
+		header = append(header, 0, 0)
+		binary.BigEndian.PutUint16(header[2:], uint16(length))
+	default:
+		header[1] = 127
+		header = append(header, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(header[2:], uint64(length))
+	}
+	if !c.server {
+		var mask [4]byte
+		if _, err := rand.Read(mask[:]); err != nil {
+			return err
+		}
+		header[1] |= maskBit
+		header = append(header, mask[:]...)
+		// do not modify the caller's data
+		masked := make([]byte, length)
+		copy(masked, payload)
+		maskBytes(mask, masked)
+		payload = masked
+	}
+	_, err := c.conn.Write(append(header, payload...))
+	return err
+}
+
+// maskBytes XORs data with the mask key
+func maskBytes(mask [4]byte, data []byte) {
+	for i := range data {
+		data[i] ^= mask[i&3]
+	}
+}
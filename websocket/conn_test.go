@@ -0,0 +1,384 @@
+package websocket
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"strings"
+	"testing"
+)
+
+// pipe returns the two ends of an in-memory connection
+func pipe(t *testing.T, cfg Config) (server, client *Conn) {
+	t.Helper()
+	if err := cfg.setDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	a, b := net.Pipe()
+	t.Cleanup(func() {
+		a.Close()
+		b.Close()
+	})
+	return newConn(a, nil, true, &cfg), newConn(b, nil, false, &cfg)
+}
+
+// writeRaw writes data to the peer of conn without waiting for it to be read
+func writeRaw(conn *Conn, data []byte) {
+	go func() {
+		_, _ = conn.conn.Write(data)
+	}()
+}
+
+// frame encodes a frame, masked with key if it is not nil
+func frame(first byte, payload []byte, key []byte) []byte {
+	b := []byte{first, 0}
+	switch {
+	case len(payload) <= 125:
+		b[1] = byte(len(payload))
+	case len(payload) <= 0xffff:
+		b[1] = 126
+		b = append(b, 0, 0)
+		binary.BigEndian.PutUint16(b[2:], uint16(len(payload)))
+	default:
+		b[1] = 127
+		b = append(b, make([]byte, 8)...)
+		binary.BigEndian.PutUint64(b[2:], uint64(len(payload)))
+	}
+	payload = append([]byte(nil), payload...)
+	if key != nil {
+		b[1] |= maskBit
+		b = append(b, key...)
+		var mask [4]byte
+		copy(mask[:], key)
+		maskBytes(mask, payload)
+	}
+	return append(b, payload...)
+}
+
+// discard reads and drops everything sent to conn, net.Pipe writes block
+// until they are read
+func discard(conn *Conn) {
+	go func() {
+		_, _ = io.Copy(ioutil.Discard, conn.conn)
+	}()
+}
+
+var testMask = []byte{1, 2, 3, 4}
+
+func TestFraming(t *testing.T) {
+	tests := []struct {
+		name        string
+		messageType int
+		size        int
+	}{
+		{"empty text", TextMessage, 0},
+		{"7 bit length", TextMessage, 125},
+		{"16 bit length", BinaryMessage, 126},
+		{"max 16 bit length", BinaryMessage, 0xffff},
+		{"64 bit length", BinaryMessage, 0x10000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, client := pipe(t, Config{WriteBufferSize: 1 << 20})
+			data := bytes.Repeat([]byte("a"), tt.size)
+			// both directions, client frames are masked
+			for _, ends := range [][2]*Conn{{client, server}, {server, client}} {
+				errc := make(chan error, 1)
+				go func(w *Conn) { errc <- w.WriteMessage(tt.messageType, data) }(ends[0])
+				messageType, got, err := ends[1].ReadMessage()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := <-errc; err != nil {
+					t.Fatal(err)
+				}
+				if messageType != tt.messageType || !bytes.Equal(got, data) {
+					t.Errorf("ReadMessage = %d %d bytes, want %d %d bytes", messageType, len(got), tt.messageType, tt.size)
+				}
+			}
+		})
+	}
+}
+
+func TestMasking(t *testing.T) {
+	tests := []struct {
+		name     string
+		server   bool
+		data     []byte
+		wantErr  string
+		wantText string
+	}{
+		{"masked client frame", true, frame(finBit|TextMessage, []byte("hello"), testMask), "", "hello"},
+		{"unmasked client frame", true, frame(finBit|TextMessage, []byte("hello"), nil), "unmasked client frame", ""},
+		{"unmasked server frame", false, frame(finBit|TextMessage, []byte("hello"), nil), "", "hello"},
+		{"masked server frame", false, frame(finBit|TextMessage, []byte("hello"), testMask), "masked server frame", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, client := pipe(t, Config{})
+			reader, peer := server, client
+			if !tt.server {
+				reader, peer = client, server
+			}
+			writeRaw(peer, tt.data)
+			discard(peer)
+			_, got, err := reader.ReadMessage()
+			if tt.wantErr != "" {
+				if !IsCloseError(err, CloseProtocolError) || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("err = %v, want protocol error %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || string(got) != tt.wantText {
+				t.Errorf("ReadMessage = %q, %v", got, err)
+			}
+		})
+	}
+
+	// writing does not modify the caller's data
+	server, client := pipe(t, Config{})
+	data := []byte("keep")
+	go func() { _ = client.WriteMessage(BinaryMessage, data) }()
+	if _, _, err := server.ReadMessage(); err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "keep" {
+		t.Errorf("data = %q after write", data)
+	}
+}
+
+func TestFragmentation(t *testing.T) {
+	tests := []struct {
+		name     string
+		frames   [][]byte
+		wantErr  int
+		wantText string
+		wantPong bool
+	}{
+		{"fragments", [][]byte{
+			frame(TextMessage, []byte("hel"), testMask),
+			frame(continuationFrame, []byte("lo "), testMask),
+			frame(finBit|continuationFrame, []byte("world"), testMask),
+		}, 0, "hello world", false},
+		{"ping between fragments", [][]byte{
+			frame(TextMessage, []byte("a"), testMask),
+			frame(finBit|PingMessage, []byte("p"), testMask),
+			frame(finBit|continuationFrame, []byte("b"), testMask),
+		}, 0, "ab", true},
+		{"continuation first", [][]byte{frame(finBit|continuationFrame, []byte("a"), testMask)}, CloseProtocolError, "", false},
+		{"new message in fragments", [][]byte{
+			frame(TextMessage, []byte("a"), testMask),
+			frame(finBit|TextMessage, []byte("b"), testMask),
+		}, CloseProtocolError, "", false},
+		{"fragmented control frame", [][]byte{frame(PingMessage, nil, testMask)}, CloseProtocolError, "", false},
+		{"long control frame", [][]byte{frame(finBit|PingMessage, make([]byte, 126), testMask)}, CloseProtocolError, "", false},
+		{"unknown opcode", [][]byte{frame(finBit|3, nil, testMask)}, CloseProtocolError, "", false},
+		{"rsv without compression", [][]byte{frame(finBit|rsv1Bit|TextMessage, nil, testMask)}, CloseProtocolError, "", false},
+		{"invalid utf-8 across fragments", [][]byte{
+			frame(TextMessage, []byte{0xe2, 0x82}, testMask),
+			frame(finBit|continuationFrame, []byte{0x28}, testMask),
+		}, CloseInvalidFramePayloadData, "", false},
+		{"utf-8 split across fragments", [][]byte{
+			frame(TextMessage, []byte{0xe2, 0x82}, testMask),
+			frame(finBit|continuationFrame, []byte{0xac}, testMask),
+		}, 0, "€", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, client := pipe(t, Config{})
+			writeRaw(client, bytes.Join(tt.frames, nil))
+			pongs := make(chan string, 1)
+			client.SetPongHandler(func(data []byte) error {
+				pongs <- string(data)
+				return nil
+			})
+			if tt.wantPong {
+				// the client reads the pong while the server reads the message
+				go func() { _, _, _ = client.ReadMessage() }()
+			} else {
+				discard(client)
+			}
+			_, got, err := server.ReadMessage()
+			if tt.wantErr != 0 {
+				if !IsCloseError(err, tt.wantErr) {
+					t.Errorf("err = %v, want close %d", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || string(got) != tt.wantText {
+				t.Fatalf("ReadMessage = %q, %v", got, err)
+			}
+			if tt.wantPong {
+				if pong := <-pongs; pong != "p" {
+					t.Errorf("pong = %q", pong)
+				}
+			}
+		})
+	}
+
+	// WriteBufferSize splits messages into frames
+	server, client := pipe(t, Config{WriteBufferSize: 4})
+	go func() { _ = client.WriteText("hello world") }()
+	var frames int
+	for {
+		h, err := server.readFrameHeader()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := server.readPayload(h, new(bytes.Buffer)); err != nil {
+			t.Fatal(err)
+		}
+		if frames++; h.fin {
+			break
+		}
+	}
+	if frames != 3 {
+		t.Errorf("frames = %d, want 3", frames)
+	}
+}
+
+func TestCloseHandshake(t *testing.T) {
+	tests := []struct {
+		name     string
+		payload  []byte
+		wantCode int
+		wantText string
+		// code echoed by the server
+		wantSent int
+	}{
+		{"normal", closePayload(CloseNormalClosure, "bye"), CloseNormalClosure, "bye", CloseNormalClosure},
+		{"application code", closePayload(4000, ""), 4000, "", 4000},
+		{"no status", nil, CloseNoStatusReceived, "", 0},
+		{"one byte", []byte{3}, CloseProtocolError, "invalid close payload", CloseProtocolError},
+		{"reserved code", closePayload(CloseNoStatusReceived, ""), CloseProtocolError, "invalid close code", CloseProtocolError},
+		{"invalid code", closePayload(999, ""), CloseProtocolError, "invalid close code", CloseProtocolError},
+		{"invalid utf-8", closePayload(CloseNormalClosure, "\xff"), CloseInvalidFramePayloadData, "invalid UTF-8", CloseInvalidFramePayloadData},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, client := pipe(t, Config{})
+			writeRaw(client, frame(finBit|CloseMessage, tt.payload, testMask))
+			// the server answers the close frame
+			reply := make(chan error, 1)
+			go func() {
+				_, _, err := client.ReadMessage()
+				reply <- err
+			}()
+			_, _, err := server.ReadMessage()
+			discard(server)
+			var closeErr *CloseError
+			if !errors.As(err, &closeErr) || closeErr.Code != tt.wantCode || closeErr.Text != tt.wantText {
+				t.Fatalf("err = %v, want close %d %q", err, tt.wantCode, tt.wantText)
+			}
+			if err := <-reply; tt.wantSent == 0 && !IsCloseError(err, CloseNoStatusReceived) ||
+				tt.wantSent != 0 && !IsCloseError(err, tt.wantSent) {
+				t.Errorf("reply = %v, want close %d", err, tt.wantSent)
+			}
+			// later reads return the same error, writes fail
+			if _, _, again := server.ReadMessage(); again != err {
+				t.Errorf("second ReadMessage = %v", again)
+			}
+			if err := server.WriteText("late"); err != ErrCloseSent {
+				t.Errorf("WriteText after close = %v, want ErrCloseSent", err)
+			}
+		})
+	}
+}
+
+func closePayload(code int, text string) []byte {
+	payload := make([]byte, 2)
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	return append(payload, text...)
+}
+
+func TestReadLimit(t *testing.T) {
+	tests := []struct {
+		name     string
+		limit    int64
+		frames   [][]byte
+		wantErr  bool
+		compress bool
+	}{
+		{"under limit", 8, [][]byte{frame(finBit|BinaryMessage, make([]byte, 8), testMask)}, false, false},
+		{"over limit", 8, [][]byte{frame(finBit|BinaryMessage, make([]byte, 9), testMask)}, true, false},
+		{"fragments over limit", 8, [][]byte{
+			frame(BinaryMessage, make([]byte, 5), testMask),
+			frame(finBit|continuationFrame, make([]byte, 5), testMask),
+		}, true, false},
+		{"inflated over limit", 64, [][]byte{compressedFrame(t, make([]byte, 65))}, true, true},
+		{"inflated under limit", 64, [][]byte{compressedFrame(t, make([]byte, 64))}, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, client := pipe(t, Config{ReadLimit: tt.limit})
+			server.compress = tt.compress
+			writeRaw(client, bytes.Join(tt.frames, nil))
+			discard(client)
+			_, _, err := server.ReadMessage()
+			if tt.wantErr != IsCloseError(err, CloseMessageTooBig) || !tt.wantErr && err != nil {
+				t.Errorf("err = %v, want too big %t", err, tt.wantErr)
+			}
+		})
+	}
+
+	// a huge length is refused before reading or allocating the payload
+	server, client := pipe(t, Config{})
+	header := []byte{finBit | BinaryMessage, maskBit | 127, 0, 0, 1, 0, 0, 0, 0, 0}
+	writeRaw(client, append(header, testMask...))
+	discard(client)
+	if _, _, err := server.ReadMessage(); !IsCloseError(err, CloseMessageTooBig) {
+		t.Errorf("err = %v, want too big", err)
+	}
+
+	server.SetReadLimit(0)
+	if server.readLimit != DefaultReadLimit {
+		t.Errorf("SetReadLimit(0) = %d, want DefaultReadLimit", server.readLimit)
+	}
+}
+
+// compressedFrame a masked compressed binary frame of data
+func compressedFrame(t *testing.T, data []byte) []byte {
+	compressed, err := compress(data, flate.BestCompression)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return frame(finBit|rsv1Bit|BinaryMessage, compressed, testMask)
+}
+
+func TestCompression(t *testing.T) {
+	data := []byte(strings.Repeat("compressible ", 100))
+	for level := flate.HuffmanOnly; level <= flate.BestCompression; level++ {
+		compressed, err := compress(data, level)
+		if err != nil {
+			t.Fatalf("compress level %d: %v", level, err)
+		}
+		if bytes.HasSuffix(compressed, deflateTail) {
+			t.Errorf("level %d: tail not removed", level)
+		}
+		got, err := decompress(compressed, int64(len(data)))
+		if err != nil || !bytes.Equal(got, data) {
+			t.Errorf("level %d: decompress = %d bytes, %v", level, len(got), err)
+		}
+	}
+	for _, level := range []int{-3, 10} {
+		if _, err := compress(data, level); err == nil {
+			t.Errorf("compress level %d = nil error", level)
+		}
+	}
+	if _, err := decompress([]byte{0xff, 0xff}, 100); !IsCloseError(err, CloseInvalidFramePayloadData) {
+		t.Errorf("decompress(garbage) = %v", err)
+	}
+
+	// compressed messages are fragmented with RSV1 only on the first frame
+	server, client := pipe(t, Config{WriteBufferSize: 16})
+	server.compress, client.compress = true, true
+	go func() { _ = client.WriteMessage(TextMessage, data) }()
+	messageType, got, err := server.ReadMessage()
+	if err != nil || messageType != TextMessage || !bytes.Equal(got, data) {
+		t.Errorf("ReadMessage = %d %q, %v", messageType, got, err)
+	}
+}
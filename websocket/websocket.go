@@ -0,0 +1,361 @@
+// Package websocket implements the WebSocket protocol (RFC 6455) with the
+// permessage-deflate extension (RFC 7692) for seng.
+//
+//	e.GET("/ws/:room", websocket.New(func(conn *websocket.Conn) {
+//		room := conn.Params["room"]
+//		for {
+//			messageType, data, err := conn.ReadMessage()
+//			if err != nil {
+//				return
+//			}
+//			if err := conn.WriteMessage(messageType, data); err != nil {
+//				return
+//			}
+//		}
+//	}))
+package websocket
+
+import (
+	"compress/flate"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/seefs001/seng"
+)
+
+// Message types
+const (
+	TextMessage   = 1
+	BinaryMessage = 2
+	CloseMessage  = 8
+	PingMessage   = 9
+	PongMessage   = 10
+
+	continuationFrame = 0
+)
+
+// Close codes
+const (
+	CloseNormalClosure           = 1000
+	CloseGoingAway               = 1001
+	CloseProtocolError           = 1002
+	CloseUnsupportedData         = 1003
+	CloseNoStatusReceived        = 1005
+	CloseAbnormalClosure         = 1006
+	CloseInvalidFramePayloadData = 1007
+	ClosePolicyViolation         = 1008
+	CloseMessageTooBig           = 1009
+	CloseMandatoryExtension      = 1010
+	CloseInternalServerErr       = 1011
+	CloseServiceRestart          = 1012
+	CloseTryAgainLater           = 1013
+	CloseTLSHandshake            = 1015
+)
+
+// Default Config values
+const (
+	DefaultReadLimit        = 4 * 1024 * 1024
+	DefaultWriteBufferSize  = 4096
+	DefaultCompressionLevel = flate.BestSpeed
+)
+
+// acceptGUID is appended to Sec-WebSocket-Key by RFC 6455
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// permessage-deflate extension
+const (
+	extensionDeflate                = "permessage-deflate"
+	paramServerNoContextTakeover    = "server_no_context_takeover"
+	paramClientNoContextTakeover    = "client_no_context_takeover"
+	paramServerMaxWindowBits        = "server_max_window_bits"
+	paramClientMaxWindowBits        = "client_max_window_bits"
+	deflateNoContextTakeoverOptions = extensionDeflate + "; " + paramServerNoContextTakeover + "; " + paramClientNoContextTakeover
+)
+
+// Errors
+var (
+	ErrCloseSent    = errors.New("websocket: close sent")
+	ErrBadHandshake = errors.New("websocket: bad handshake")
+)
+
+// CloseError is returned by ReadMessage when the connection is closed
+type CloseError struct {
+	Code int
+	Text string
+}
+
+// Error implements error
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("websocket: close %d %s", e.Code, e.Text)
+}
+
+// IsCloseError reports whether err is a CloseError with one of codes
+func IsCloseError(err error, codes ...int) bool {
+	var e *CloseError
+	if !errors.As(err, &e) {
+		return false
+	}
+	for _, code := range codes {
+		if e.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Config of the WebSocket handler
+type Config struct {
+	// Origins allowed to connect, "*" allows all.
+	// Default: the origin of the request Host
+	Origins []string
+	// CheckOrigin overrides Origins
+	CheckOrigin func(r *http.Request) bool
+	// Subprotocols supported by the server in order of preference
+	Subprotocols []string
+	// EnableCompression negotiates permessage-deflate
+	// Default: false
+	EnableCompression bool
+	// CompressionLevel flate.HuffmanOnly to flate.BestCompression,
+	// New panics on other levels. 0 is the default, as flate.NoCompression
+	// would only add overhead, disable EnableCompression instead.
+	// Default: flate.BestSpeed
+	CompressionLevel int
+	// ReadLimit max size of a message, larger messages close the connection
+	// Default: 4 * 1024 * 1024
+	ReadLimit int64
+	// WriteBufferSize max payload of a frame, larger messages are fragmented
+	// Default: 4096
+	WriteBufferSize int
+}
+
+// New returns a handler which upgrades the request and calls handler,
+// the connection is closed when handler returns
+func New(handler func(conn *Conn), config ...Config) seng.Handler {
+	cfg := Config{}
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if err := cfg.setDefaults(); err != nil {
+		panic(err)
+	}
+	return func(c *seng.Context) error {
+		conn, err := cfg.upgrade(c)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		handler(conn)
+		return nil
+	}
+}
+
+// setDefaults sets the default values and checks CompressionLevel
+func (cfg *Config) setDefaults() error {
+	if cfg.CompressionLevel == 0 {
+		cfg.CompressionLevel = DefaultCompressionLevel
+	}
+	if cfg.CompressionLevel < flate.HuffmanOnly || cfg.CompressionLevel > flate.BestCompression {
+		return fmt.Errorf("websocket: invalid compression level %d", cfg.CompressionLevel)
+	}
+	if cfg.ReadLimit <= 0 {
+		cfg.ReadLimit = DefaultReadLimit
+	}
+	if cfg.WriteBufferSize <= 0 {
+		cfg.WriteBufferSize = DefaultWriteBufferSize
+	}
+	return nil
+}
+
+// IsWebSocketUpgrade reports whether the request asks for a WebSocket upgrade
+func IsWebSocketUpgrade(r *http.Request) bool {
+	return headerContainsToken(r.Header, "Connection", "upgrade") &&
+		headerContainsToken(r.Header, "Upgrade", "websocket")
+}
+
+// upgrade performs the opening handshake
+func (cfg *Config) upgrade(c *seng.Context) (*Conn, error) {
+	r := c.Request
+	if r.Method != http.MethodGet {
+		return nil, seng.NewError(http.StatusMethodNotAllowed, "websocket: upgrade requires GET")
+	}
+	if !IsWebSocketUpgrade(r) {
+		return nil, seng.NewError(http.StatusBadRequest, "websocket: not a websocket upgrade")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		c.SetHeader("Sec-WebSocket-Version", "13")
+		return nil, seng.NewError(http.StatusUpgradeRequired, "websocket: unsupported version")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		return nil, seng.NewError(http.StatusBadRequest, "websocket: invalid Sec-WebSocket-Key")
+	}
+	if !cfg.checkOrigin(r) {
+		return nil, seng.NewError(http.StatusForbidden, "websocket: origin not allowed")
+	}
+	hijacker, ok := c.Writer.(http.Hijacker)
+	if !ok {
+		return nil, seng.NewError(http.StatusInternalServerError, "websocket: response does not implement http.Hijacker")
+	}
+
+	subprotocol := cfg.selectSubprotocol(r)
+	compress := cfg.EnableCompression && acceptDeflate(r.Header)
+
+	netConn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n"
+	if subprotocol != "" {
+		response += "Sec-WebSocket-Protocol: " + subprotocol + "\r\n"
+	}
+	if compress {
+		response += "Sec-WebSocket-Extensions: " + deflateNoContextTakeoverOptions + "\r\n"
+	}
+	response += "\r\n"
+	if _, err := netConn.Write([]byte(response)); err != nil {
+		_ = netConn.Close()
+		return nil, err
+	}
+
+	conn := newConn(netConn, rw.Reader, true, cfg)
+	conn.subprotocol = subprotocol
+	conn.compress = compress
+	conn.Params = c.Params
+	conn.Values = c.Values
+	conn.Request = r
+	return conn, nil
+}
+
+// checkOrigin allows requests without Origin, from the same host or from Origins
+func (cfg *Config) checkOrigin(r *http.Request) bool {
+	if cfg.CheckOrigin != nil {
+		return cfg.CheckOrigin(r)
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	for _, allowed := range cfg.Origins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+// selectSubprotocol returns the first of Subprotocols requested by the client
+func (cfg *Config) selectSubprotocol(r *http.Request) string {
+	requested := headerTokens(r.Header, "Sec-WebSocket-Protocol")
+	for _, supported := range cfg.Subprotocols {
+		for _, protocol := range requested {
+			if protocol == supported {
+				return protocol
+			}
+		}
+	}
+	return ""
+}
+
+// acceptKey returns the Sec-WebSocket-Accept value of key
+func acceptKey(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// acceptDeflate reports whether a permessage-deflate offer can be accepted
+// without context takeover and with the full window
+func acceptDeflate(header http.Header) bool {
+	for _, extension := range parseExtensions(header) {
+		if extension.name != extensionDeflate {
+			continue
+		}
+		ok := true
+		for key, value := range extension.params {
+			switch key {
+			case paramServerNoContextTakeover, paramClientNoContextTakeover, paramClientMaxWindowBits:
+			case paramServerMaxWindowBits:
+				// compress/flate always uses a 32K window
+				ok = ok && value == "15"
+			default:
+				ok = false
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// extension of Sec-WebSocket-Extensions
+type extension struct {
+	name   string
+	params map[string]string
+}
+
+// parseExtensions "permessage-deflate; client_max_window_bits, x-foo"
+func parseExtensions(header http.Header) []extension {
+	var extensions []extension
+	for _, value := range header.Values("Sec-WebSocket-Extensions") {
+		for _, offer := range strings.Split(value, ",") {
+			parts := strings.Split(offer, ";")
+			ext := extension{
+				name:   strings.ToLower(strings.TrimSpace(parts[0])),
+				params: make(map[string]string),
+			}
+			if ext.name == "" {
+				continue
+			}
+			for _, param := range parts[1:] {
+				kv := strings.SplitN(param, "=", 2)
+				key := strings.ToLower(strings.TrimSpace(kv[0]))
+				if key == "" {
+					continue
+				}
+				if len(kv) == 2 {
+					ext.params[key] = strings.Trim(strings.TrimSpace(kv[1]), `"`)
+				} else {
+					ext.params[key] = ""
+				}
+			}
+			extensions = append(extensions, ext)
+		}
+	}
+	return extensions
+}
+
+// headerTokens returns the comma separated tokens of a header
+func headerTokens(header http.Header, name string) []string {
+	var tokens []string
+	for _, value := range header.Values(name) {
+		for _, token := range strings.Split(value, ",") {
+			if token = strings.TrimSpace(token); token != "" {
+				tokens = append(tokens, token)
+			}
+		}
+	}
+	return tokens
+}
+
+// headerContainsToken reports whether a header contains token, case-insensitively
+func headerContainsToken(header http.Header, name, token string) bool {
+	for _, t := range headerTokens(header, name) {
+		if strings.EqualFold(t, token) {
+			return true
+		}
+	}
+	return false
+}
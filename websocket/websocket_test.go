@@ -0,0 +1,189 @@
+package websocket
+
+import (
+	"compress/flate"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/seefs001/seng"
+)
+
+func TestConfigDefaults(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		want    Config
+		wantErr bool
+	}{
+		{"zero", Config{}, Config{CompressionLevel: DefaultCompressionLevel, ReadLimit: DefaultReadLimit, WriteBufferSize: DefaultWriteBufferSize}, false},
+		{"huffman only", Config{CompressionLevel: flate.HuffmanOnly}, Config{CompressionLevel: flate.HuffmanOnly, ReadLimit: DefaultReadLimit, WriteBufferSize: DefaultWriteBufferSize}, false},
+		{"best compression", Config{CompressionLevel: flate.BestCompression, ReadLimit: 10, WriteBufferSize: 20}, Config{CompressionLevel: flate.BestCompression, ReadLimit: 10, WriteBufferSize: 20}, false},
+		{"negative read limit", Config{ReadLimit: -1}, Config{CompressionLevel: DefaultCompressionLevel, ReadLimit: DefaultReadLimit, WriteBufferSize: DefaultWriteBufferSize}, false},
+		{"level too low", Config{CompressionLevel: -3}, Config{}, true},
+		{"level too high", Config{CompressionLevel: 10}, Config{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.config
+			err := cfg.setDefaults()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("setDefaults = %v, want error %t", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if cfg.CompressionLevel != tt.want.CompressionLevel || cfg.ReadLimit != tt.want.ReadLimit ||
+				cfg.WriteBufferSize != tt.want.WriteBufferSize {
+				t.Errorf("config = %+v, want %+v", cfg, tt.want)
+			}
+		})
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("New with an invalid level did not panic")
+		}
+	}()
+	New(func(*Conn) {}, Config{CompressionLevel: 42})
+}
+
+func TestDialerInvalidLevel(t *testing.T) {
+	d := &Dialer{CompressionLevel: 42}
+	if _, _, err := d.Dial("ws://localhost/", nil); err == nil || !strings.Contains(err.Error(), "compression level") {
+		t.Errorf("Dial = %v, want compression level error", err)
+	}
+}
+
+// echo returns an engine echoing messages on /ws
+func echo(config ...Config) *seng.Engine {
+	e := seng.New()
+	e.GET("/ws", New(func(conn *Conn) {
+		for {
+			messageType, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := conn.WriteMessage(messageType, data); err != nil {
+				return
+			}
+		}
+	}, config...))
+	return e
+}
+
+func TestDialHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		config         Config
+		dialer         Dialer
+		wantCompressed bool
+		wantProtocol   string
+	}{
+		{"plain", Config{}, Dialer{}, false, ""},
+		{"deflate", Config{EnableCompression: true}, Dialer{EnableCompression: true}, true, ""},
+		{"deflate client only", Config{}, Dialer{EnableCompression: true}, false, ""},
+		{"deflate server only", Config{EnableCompression: true}, Dialer{}, false, ""},
+		{"deflate levels", Config{EnableCompression: true, CompressionLevel: flate.HuffmanOnly},
+			Dialer{EnableCompression: true, CompressionLevel: flate.BestCompression}, true, ""},
+		{"subprotocol", Config{Subprotocols: []string{"chat", "v2"}}, Dialer{Subprotocols: []string{"v2", "chat"}}, false, "chat"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dialer := tt.dialer
+			conn, resp, err := DialHandler(echo(tt.config), "ws://localhost/ws", nil, &dialer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+			if resp.StatusCode != http.StatusSwitchingProtocols {
+				t.Errorf("status = %d", resp.StatusCode)
+			}
+			if conn.Compressed() != tt.wantCompressed {
+				t.Errorf("Compressed = %t, want %t", conn.Compressed(), tt.wantCompressed)
+			}
+			if conn.Subprotocol() != tt.wantProtocol {
+				t.Errorf("Subprotocol = %q, want %q", conn.Subprotocol(), tt.wantProtocol)
+			}
+			for _, message := range []string{"hello", strings.Repeat("long message ", 1000)} {
+				if err := conn.WriteText(message); err != nil {
+					t.Fatal(err)
+				}
+				messageType, data, err := conn.ReadMessage()
+				if err != nil || messageType != TextMessage || string(data) != message {
+					t.Fatalf("echo = %d %d bytes, %v", messageType, len(data), err)
+				}
+			}
+			if err := conn.WriteClose(CloseNormalClosure, "done"); err != nil {
+				t.Fatal(err)
+			}
+			if _, _, err := conn.ReadMessage(); !IsCloseError(err, CloseNormalClosure) {
+				t.Errorf("close reply = %v", err)
+			}
+		})
+	}
+}
+
+func TestUpgradeErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		header   http.Header
+		wantCode int
+	}{
+		{"post", http.MethodPost, nil, http.StatusMethodNotAllowed},
+		{"not an upgrade", http.MethodGet, http.Header{"Upgrade": nil}, http.StatusBadRequest},
+		{"bad version", http.MethodGet, http.Header{"Sec-Websocket-Version": {"8"}}, http.StatusUpgradeRequired},
+		{"bad key", http.MethodGet, http.Header{"Sec-Websocket-Key": {"short"}}, http.StatusBadRequest},
+		{"other origin", http.MethodGet, http.Header{"Origin": {"http://evil.example"}}, http.StatusForbidden},
+		{"same origin", http.MethodGet, http.Header{"Origin": {"http://example.com"}}, http.StatusInternalServerError},
+	}
+	e := echo()
+	e.POST("/ws", New(func(*Conn) {}))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "http://example.com/ws", nil)
+			r.Header = http.Header{
+				"Upgrade":               {"websocket"},
+				"Connection":            {"Upgrade"},
+				"Sec-Websocket-Version": {"13"},
+				"Sec-Websocket-Key":     {"dGhlIHNhbXBsZSBub25jZQ=="},
+			}
+			for key, values := range tt.header {
+				r.Header[key] = values
+			}
+			w := httptest.NewRecorder()
+			e.ServeHTTP(w, r)
+			// the recorder cannot be hijacked, so valid upgrades fail with 500
+			if w.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestAcceptDeflate(t *testing.T) {
+	tests := []struct {
+		offer string
+		want  bool
+	}{
+		{"", false},
+		{"permessage-deflate", true},
+		{"permessage-deflate; client_max_window_bits", true},
+		{"permessage-deflate; server_max_window_bits=10", false},
+		{"permessage-deflate; server_max_window_bits=10, permessage-deflate", true},
+		{"permessage-deflate; server_max_window_bits=15; server_no_context_takeover", true},
+		{"permessage-deflate; unknown", false},
+		{"x-webkit-deflate-frame", false},
+	}
+	for _, tt := range tests {
+		header := http.Header{}
+		if tt.offer != "" {
+			header.Set("Sec-WebSocket-Extensions", tt.offer)
+		}
+		if got := acceptDeflate(header); got != tt.want {
+			t.Errorf("acceptDeflate(%q) = %t, want %t", tt.offer, got, tt.want)
+		}
+	}
+}
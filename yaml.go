@@ -0,0 +1,277 @@
+package seng
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// yamlIndent spaces per nesting level
+const yamlIndent = 2
+
+// marshalYAML encodes v as a YAML block document.
+// Struct fields use the `yaml` tag, then the `json` tag, then the field name,
+// ",omitempty" is supported. Map keys are sorted.
+func marshalYAML(v interface{}) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := encodeYAML(buf, reflect.ValueOf(v), 0); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+var (
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	jsonNumberType    = reflect.TypeOf(json.Number(""))
+)
+
+// encodeYAML writes v as a block starting at indent, ending with a newline
+func encodeYAML(buf *bytes.Buffer, v reflect.Value, indent int) error {
+	v = indirect(v)
+	if scalar, ok, err := yamlScalar(v); ok || err != nil {
+		if err != nil {
+			return err
+		}
+		writeIndent(buf, indent)
+		buf.WriteString(scalar)
+		buf.WriteByte('\n')
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Map:
+		keys := v.MapKeys()
+		names := make([]string, len(keys))
+		for i, key := range keys {
+			names[i] = fmt.Sprint(key.Interface())
+		}
+		sort.Sort(yamlKeys{names: names, keys: keys})
+		for i, key := range keys {
+			if err := encodeYAMLPair(buf, names[i], v.MapIndex(key), indent); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		return encodeYAMLStruct(buf, v, indent)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			// render the item one level deeper, then replace its first indent with "- "
+			item := new(bytes.Buffer)
+			if err := encodeYAML(item, v.Index(i), indent+yamlIndent); err != nil {
+				return err
+			}
+			writeIndent(buf, indent)
+			buf.WriteString("- ")
+			buf.Write(item.Bytes()[indent+yamlIndent:])
+		}
+	default:
+		return fmt.Errorf("seng: yaml: unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+func encodeYAMLStruct(buf *bytes.Buffer, v reflect.Value, indent int) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		fv := v.Field(i)
+		name, omitEmpty, ok := yamlFieldName(field)
+		if !ok {
+			continue
+		}
+		// fields of embedded structs are inlined
+		if field.Anonymous && name == "" {
+			if embedded := indirect(fv); embedded.Kind() == reflect.Struct {
+				if err := encodeYAMLStruct(buf, embedded, indent); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if omitEmpty && isEmptyValue(fv) {
+			continue
+		}
+		if err := encodeYAMLPair(buf, name, fv, indent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// yamlFieldName returns the name from the yaml or json tag
+func yamlFieldName(field reflect.StructField) (name string, omitEmpty bool, ok bool) {
+	tag, found := field.Tag.Lookup("yaml")
+	if !found {
+		tag = field.Tag.Get("json")
+	}
+	if tag == "-" {
+		return "", false, false
+	}
+	parts := strings.Split(tag, ",")
+	for _, option := range parts[1:] {
+		if option == "omitempty" {
+			omitEmpty = true
+		}
+	}
+	return parts[0], omitEmpty, true
+}
+
+// encodeYAMLPair writes "key: value", collections start on the next line
+func encodeYAMLPair(buf *bytes.Buffer, key string, v reflect.Value, indent int) error {
+	writeIndent(buf, indent)
+	buf.WriteString(quoteYAML(key))
+	buf.WriteByte(':')
+	v = indirect(v)
+	scalar, ok, err := yamlScalar(v)
+	if err != nil {
+		return err
+	}
+	if ok {
+		buf.WriteByte(' ')
+		buf.WriteString(scalar)
+		buf.WriteByte('\n')
+		return nil
+	}
+	buf.WriteByte('\n')
+	childIndent := indent + yamlIndent
+	// sequences of a mapping are not indented
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+		childIndent = indent
+	}
+	return encodeYAML(buf, v, childIndent)
+}
+
+// yamlScalar returns the inline form of scalars and empty collections
+func yamlScalar(v reflect.Value) (string, bool, error) {
+	if !v.IsValid() {
+		return "null", true, nil
+	}
+	if v.Type() == timeType {
+		return v.Interface().(time.Time).Format(time.RFC3339Nano), true, nil
+	}
+	if v.Type() == jsonNumberType {
+		return v.String(), true, nil
+	}
+	if v.Type().Implements(textMarshalerType) {
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return "", false, err
+		}
+		return quoteYAML(string(text)), true, nil
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		// nil after indirect
+		return "null", true, nil
+	case reflect.String:
+		return quoteYAML(v.String()), true, nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), true, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), true, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10), true, nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()), true, nil
+	case reflect.Map:
+		if v.IsNil() {
+			return "null", true, nil
+		}
+		if v.Len() == 0 {
+			return "{}", true, nil
+		}
+	case reflect.Slice:
+		if v.IsNil() {
+			return "null", true, nil
+		}
+		if v.Len() == 0 {
+			return "[]", true, nil
+		}
+	case reflect.Array:
+		if v.Len() == 0 {
+			return "[]", true, nil
+		}
+	case reflect.Struct:
+		if v.NumField() == 0 {
+			return "{}", true, nil
+		}
+	}
+	return "", false, nil
+}
+
+// yamlImplicitRegexp plain scalars that YAML 1.1 and 1.2 resolve to ints,
+// floats, timestamps, merge keys or values instead of strings
+var yamlImplicitRegexp = regexp.MustCompile(`^(?:` +
+	// ints: binary, octal, hexadecimal, decimal and sexagesimal 1:30
+	`[-+]?0b[01_]+|[-+]?0o?[0-7_]+|[-+]?0x[0-9a-fA-F_]+|[-+]?[0-9][0-9_]*(?::[0-5]?[0-9])*|` +
+	// floats: 1.5 .5 1e3 1:30.5 .inf .nan
+	`[-+]?(?:[0-9][0-9_]*)?\.[0-9_.]*(?:[eE][-+]?[0-9]+)?|[-+]?[0-9][0-9_]*(?:\.[0-9_]*)?[eE][-+]?[0-9]+|` +
+	`[-+]?[0-9][0-9_]*(?::[0-5]?[0-9])+\.[0-9_]*|[-+]?\.(?:inf|Inf|INF)|\.(?:nan|NaN|NAN)|` +
+	// timestamps: 2024-01-01, 2024-01-01T10:00:00Z, 2024-1-1 10:00:00
+	`[0-9]{4}-[0-9]{1,2}-[0-9]{1,2}(?:(?:[Tt]|[ \t]+).*)?|` +
+	// merge key and value key
+	`<<|=` +
+	`)$`)
+
+// quoteYAML double quotes strings that would not be read back as the same string
+func quoteYAML(s string) string {
+	if s == "" {
+		return `""`
+	}
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "y", "n", "null", "~":
+		return strconv.Quote(s)
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil || yamlImplicitRegexp.MatchString(s) {
+		return strconv.Quote(s)
+	}
+	if strings.ContainsAny(s[:1], "-?:,[]{}#&*!|>'\"%@` \t") ||
+		strings.ContainsAny(s, "\n\r\t\\") ||
+		strings.Contains(s, ": ") || strings.Contains(s, " #") ||
+		strings.HasSuffix(s, ":") || strings.HasSuffix(s, " ") {
+		return strconv.Quote(s)
+	}
+	for _, r := range s {
+		if !strconv.IsPrint(r) {
+			return strconv.Quote(s)
+		}
+	}
+	return s
+}
+
+func writeIndent(buf *bytes.Buffer, indent int) {
+	for i := 0; i < indent; i++ {
+		buf.WriteByte(' ')
+	}
+}
+
+// yamlKeys sorts map keys by their string form
+type yamlKeys struct {
+	names []string
+	keys  []reflect.Value
+}
+
+func (k yamlKeys) Len() int {
+	return len(k.names)
+}
+
+func (k yamlKeys) Less(i, j int) bool {
+	return k.names[i] < k.names[j]
+}
+
+func (k yamlKeys) Swap(i, j int) {
+	k.names[i], k.names[j] = k.names[j], k.names[i]
+	k.keys[i], k.keys[j] = k.keys[j], k.keys[i]
+}
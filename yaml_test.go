@@ -0,0 +1,125 @@
+package seng
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestQuoteYAML(t *testing.T) {
+	tests := []struct {
+		in     string
+		quoted bool
+	}{
+		{"hello", false},
+		{"hello world", false},
+		{"a-b", false},
+		{"1a", false},
+		{"v1.2", false},
+		{"2024", true},
+		{"", true},
+		{"yes", true},
+		{"Off", true},
+		{"~", true},
+		{"null", true},
+		{"1.5", true},
+		{"1e3", true},
+		{"0x1F", true},
+		{"0o17", true},
+		{"0755", true},
+		{"0b1010", true},
+		{"1_000", true},
+		{"+12", true},
+		{"1:30", true},
+		{"190:20:30.15", true},
+		{".5", true},
+		{"1.2.3", true},
+		{".inf", true},
+		{"-.Inf", true},
+		{".nan", true},
+		{"2024-01-01", true},
+		{"2024-1-1 10:00:00", true},
+		{"2001-12-14t21:59:43.10-05:00", true},
+		{"=", true},
+		{"<<", true},
+		{"- item", true},
+		{"key: value", true},
+		{"a #comment", true},
+		{"line\nbreak", true},
+		{"trailing ", true},
+		{"@user", true},
+	}
+	for _, tt := range tests {
+		got := quoteYAML(tt.in)
+		if quoted := got != tt.in; quoted != tt.quoted {
+			t.Errorf("quoteYAML(%q) = %s, want quoted %t", tt.in, got, tt.quoted)
+		}
+	}
+}
+
+func TestMarshalYAML(t *testing.T) {
+	type Base struct {
+		ID int `json:"id"`
+	}
+	type pet struct {
+		Base
+		Name    string            `json:"name"`
+		Tag     string            `yaml:"tag,omitempty"`
+		Secret  string            `json:"-"`
+		Born    time.Time         `json:"born"`
+		Weight  json.Number       `json:"weight"`
+		Owner   *string           `json:"owner"`
+		Toys    []string          `json:"toys"`
+		Attrs   map[string]string `json:"attrs"`
+		Friends []Base            `json:"friends"`
+		Empty   []int             `json:"empty"`
+	}
+	tests := []struct {
+		name string
+		in   interface{}
+		want string
+	}{
+		{"scalar", "0x1F", "\"0x1F\"\n"},
+		{"struct", pet{
+			Base:    Base{ID: 1},
+			Name:    "2024-01-01",
+			Secret:  "s",
+			Born:    time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+			Weight:  "4.5",
+			Toys:    []string{"ball", "yes"},
+			Attrs:   map[string]string{"b": "2", "a": "x"},
+			Friends: []Base{{ID: 2}},
+			Empty:   []int{},
+		}, `id: 1
+name: "2024-01-01"
+born: 2020-01-02T03:04:05Z
+weight: 4.5
+owner: null
+toys:
+- ball
+- "yes"
+attrs:
+  a: x
+  b: "2"
+friends:
+- id: 2
+empty: []
+`},
+		{"nested slices", [][]int{{1, 2}, {3}}, "- - 1\n  - 2\n- - 3\n"},
+		{"map keys", map[string]bool{"on": true, "=": false}, "\"=\": false\n\"on\": true\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := marshalYAML(tt.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("marshalYAML =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+	if _, err := marshalYAML(make(chan int)); err == nil {
+		t.Error("marshalYAML(chan) = nil error")
+	}
+}